@@ -0,0 +1,48 @@
+package oteltracing
+
+import (
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// defaultBaggageValueLimit is the default maximum length, in bytes, of a baggage value recorded as attribute.
+const defaultBaggageValueLimit = 128
+
+// baggageAttrs determines which baggage members are recorded as span and metric attributes.
+type baggageAttrs struct {
+	span    []string
+	metrics []string
+	limit   int
+}
+
+// attributes returns the attributes for the members of bag listed in keys. The attribute key is the member key, and
+// the value is truncated to the configured limit.
+func (ba *baggageAttrs) attributes(bag baggage.Baggage, keys []string) []attribute.KeyValue {
+	if len(keys) == 0 || bag.Len() == 0 {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	for _, key := range keys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, truncate(member.Value(), ba.limit)))
+	}
+	return attrs
+}
+
+// truncate returns s truncated to at most limit bytes, without splitting a multi-byte character. A limit of zero or
+// less disables truncation.
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
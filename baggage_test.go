@@ -0,0 +1,150 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithBaggageAttributes(t *testing.T) {
+	cases := []struct {
+		name        string
+		opts        []Option
+		route       []fox.RouteOption
+		wantSpan    []attribute.KeyValue
+		wantMetrics []attribute.KeyValue
+	}{
+		{
+			name: "disabled by default",
+		},
+		{
+			name: "span only",
+			opts: []Option{WithBaggageAttributes("tenant.id", "client.version", "missing")},
+			wantSpan: []attribute.KeyValue{
+				attribute.String("tenant.id", "acme"),
+				attribute.String("client.version", strings.Repeat("v", 128)),
+			},
+		},
+		{
+			name: "span and metrics",
+			opts: []Option{WithBaggageAttributes("tenant.id", "client.version"), WithBaggageMetricsAttributes("tenant.id")},
+			wantSpan: []attribute.KeyValue{
+				attribute.String("tenant.id", "acme"),
+				attribute.String("client.version", strings.Repeat("v", 128)),
+			},
+			wantMetrics: []attribute.KeyValue{
+				attribute.String("tenant.id", "acme"),
+			},
+		},
+		{
+			name:  "metrics without tracing",
+			opts:  []Option{WithBaggageAttributes("tenant.id"), WithBaggageMetricsAttributes("tenant.id")},
+			route: []fox.RouteOption{RouteConfig(WithoutRouteTracing())},
+			wantMetrics: []attribute.KeyValue{
+				attribute.String("tenant.id", "acme"),
+			},
+		},
+		{
+			name: "public endpoint",
+			opts: []Option{
+				WithBaggageAttributes("tenant.id"),
+				WithBaggageMetricsAttributes("tenant.id"),
+				WithPublicEndpoint(),
+			},
+		},
+		{
+			name: "public endpoint without tracing",
+			opts: []Option{
+				WithBaggageMetricsAttributes("tenant.id"),
+				WithPublicEndpointFn(func(c *fox.Context) bool { return true }),
+			},
+			route: []fox.RouteOption{RouteConfig(WithoutRouteTracing())},
+		},
+		{
+			name: "value limit",
+			opts: []Option{WithBaggageAttributes("client.version"), WithBaggageValueLimit(4)},
+			wantSpan: []attribute.KeyValue{
+				attribute.String("client.version", "vvvv"),
+			},
+		},
+		{
+			name: "no value limit",
+			opts: []Option{WithBaggageValueLimit(0), WithBaggageAttributes("client.version")},
+			wantSpan: []attribute.KeyValue{
+				attribute.String("client.version", strings.Repeat("v", 200)),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			prop := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter), WithPropagators(prop))...)),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {}, tc.route...)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+			req.Header.Set("Baggage", "tenant.id=acme,client.version="+strings.Repeat("v", 200)+",other=value")
+			f.ServeHTTP(httptest.NewRecorder(), req)
+
+			var gotSpan []attribute.KeyValue
+			for _, span := range sr.Ended() {
+				for _, attr := range span.Attributes() {
+					switch attr.Key {
+					case "tenant.id", "client.version", "other", "missing":
+						gotSpan = append(gotSpan, attr)
+					}
+				}
+			}
+			assert.ElementsMatch(t, tc.wantSpan, gotSpan)
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			var gotMetrics []attribute.KeyValue
+			for _, attr := range hist.DataPoints[0].Attributes.ToSlice() {
+				switch attr.Key {
+				case "tenant.id", "client.version", "other", "missing":
+					gotMetrics = append(gotMetrics, attr)
+				}
+			}
+			assert.ElementsMatch(t, tc.wantMetrics, gotMetrics)
+		})
+	}
+}
+
+func TestBaggageValueTruncate(t *testing.T) {
+	member, err := baggage.NewMember("name", "h%C3%A9llo")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	ba := &baggageAttrs{limit: 2}
+	// The value is not split in the middle of a multi-byte character.
+	assert.Equal(t, []attribute.KeyValue{attribute.String("name", "h")}, ba.attributes(bag, []string{"name"}))
+
+	ba.limit = 3
+	assert.Equal(t, []attribute.KeyValue{attribute.String("name", "hé")}, ba.attributes(bag, []string{"name"}))
+}
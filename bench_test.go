@@ -0,0 +1,77 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// BenchmarkMiddleware measures the steady-state cost of the middleware. The "with metrics attributes" case bypasses
+// the attribute set cache, since the attributes returned by the MetricAttributesFunc may change on each request. The
+// "varying hosts" case sends a distinct Host header on each request, which must not defeat the cache.
+func BenchmarkMiddleware(b *testing.B) {
+	cases := []struct {
+		name         string
+		opts         []Option
+		varyingHosts bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "with metrics attributes",
+			opts: []Option{
+				WithMetricsAttributes(func(c *fox.Context) []attribute.KeyValue {
+					return []attribute.KeyValue{attribute.String("tenant", c.Param("tenant"))}
+				}),
+			},
+		},
+		{
+			name:         "varying hosts",
+			varyingHosts: true,
+		},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter))...)),
+			)
+			require.NoError(b, err)
+			_, err = f.Add(fox.MethodGet, "/tenants/{tenant}/users", func(c *fox.Context) {
+				c.Writer().WriteHeader(http.StatusOK)
+			})
+			require.NoError(b, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/tenants/acme/users", nil)
+			w := httptest.NewRecorder()
+
+			var hosts []string
+			if tc.varyingHosts {
+				hosts = make([]string, 2*maxCachedAttributeSets)
+				for i := range hosts {
+					hosts[i] = "host" + strconv.Itoa(i) + ".example.com"
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				if hosts != nil {
+					req.Host = hosts[i%len(hosts)]
+				}
+				f.ServeHTTP(w, req)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package oteltracing
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// bodyWrapper wraps an [io.ReadCloser] to track the number of bytes actually read and the first read error. The body
+// may be read concurrently with the handler, so the counters are safe for concurrent use.
+type bodyWrapper struct {
+	io.ReadCloser
+	span trace.Span
+	read atomic.Int64
+	err  atomic.Pointer[error]
+}
+
+func newBodyWrapper(body io.ReadCloser, span trace.Span) *bodyWrapper {
+	return &bodyWrapper{ReadCloser: body, span: span}
+}
+
+// Read reads up to len(b) bytes from the underlying body, recording the number of bytes read. The first error
+// other than [io.EOF] is recorded as an exception event on the span.
+func (w *bodyWrapper) Read(b []byte) (int, error) {
+	n, err := w.ReadCloser.Read(b)
+	w.read.Add(int64(n))
+	if err != nil && !errors.Is(err, io.EOF) && w.err.CompareAndSwap(nil, &err) {
+		w.span.RecordError(err)
+	}
+	return n, err
+}
+
+// BytesRead returns the number of bytes read so far.
+func (w *bodyWrapper) BytesRead() int64 {
+	return w.read.Load()
+}
+
+// ReadError returns the first error, other than [io.EOF], encountered while reading the body.
+func (w *bodyWrapper) ReadError() error {
+	if err := w.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package oteltracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestRequestBodySize(t *testing.T) {
+	errRead := errors.New("connection reset by peer")
+
+	cases := []struct {
+		name       string
+		body       io.Reader
+		read       int64
+		wantSize   int64
+		wantEvents int
+	}{
+		{
+			name:     "chunked body fully read",
+			body:     io.MultiReader(strings.NewReader("hello "), strings.NewReader("world")),
+			read:     -1,
+			wantSize: 11,
+		},
+		{
+			name:     "body partially read",
+			body:     strings.NewReader("hello world"),
+			read:     5,
+			wantSize: 5,
+		},
+		{
+			name:     "body not read",
+			body:     strings.NewReader("hello world"),
+			read:     0,
+			wantSize: 0,
+		},
+		{
+			name:       "body read error",
+			body:       io.MultiReader(strings.NewReader("hello"), errReader{err: errRead}),
+			read:       -1,
+			wantSize:   5,
+			wantEvents: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter))),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodPost, "/upload", func(c *fox.Context) {
+				switch {
+				case tc.read < 0:
+					_, _ = io.Copy(io.Discard, c.Request().Body)
+				case tc.read > 0:
+					_, _ = io.CopyN(io.Discard, c.Request().Body, tc.read)
+				}
+				_ = c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/upload", tc.body)
+			r.ContentLength = -1
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			if tc.wantSize > 0 {
+				assert.Contains(t, span.Attributes(), attribute.Int("http.request.body.size", int(tc.wantSize)))
+			}
+			require.Len(t, span.Events(), tc.wantEvents)
+			if tc.wantEvents > 0 {
+				assert.Contains(t, span.Events()[0].Attributes, attribute.String("exception.message", errRead.Error()))
+			}
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.body.size").Data.(metricdata.Histogram[int64])
+			require.Len(t, hist.DataPoints, 1)
+			assert.Equal(t, tc.wantSize, hist.DataPoints[0].Sum)
+		})
+	}
+}
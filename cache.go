@@ -0,0 +1,64 @@
+package oteltracing
+
+import (
+	"sync"
+
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+)
+
+// maxCachedAttributeSets bounds the number of entries of each attribute set cache. The cache keys only hold the
+// normalized values from which the attributes are derived, but the request host is still controlled by the client if
+// the service name is empty, so the caches stop growing once the limit is reached and the attribute sets of new
+// combinations are computed for each request.
+const maxCachedAttributeSets = 1024
+
+// metricKey identifies the attributes of the request metrics. It holds the values from which the attributes are
+// derived, normalized like the attributes (e.g. unknown methods are folded into "_OTHER"), so that junk input does not
+// fill the cache. The route configuration is part of the key since it may carry static metric attributes.
+type metricKey struct {
+	rc        *routeConfig
+	server    semconv.ServerKey
+	route     string
+	proto     string
+	errorType string
+	status    int
+}
+
+// activeKey identifies the attributes of the "http.server.active_requests" metric.
+type activeKey struct {
+	server semconv.ServerKey
+	route  string
+}
+
+// boundedCache is a concurrent cache that stops accepting new entries once its limit is reached. Entries are never
+// evicted, the cache is meant to hold the precomputed values of a bounded set of keys (e.g. per route).
+type boundedCache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	items map[K]V
+	limit int
+}
+
+func newBoundedCache[K comparable, V any](limit int) *boundedCache[K, V] {
+	return &boundedCache[K, V]{
+		items: make(map[K]V),
+		limit: limit,
+	}
+}
+
+// get returns the value for the key, or computes it with build and stores it if the cache is not full.
+func (c *boundedCache[K, V]) get(key K, build func() V) V {
+	c.mu.RLock()
+	v, ok := c.items[key]
+	c.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	v = build()
+	c.mu.Lock()
+	if _, ok := c.items[key]; !ok && len(c.items) < c.limit {
+		c.items[key] = v
+	}
+	c.mu.Unlock()
+	return v
+}
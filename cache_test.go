@@ -0,0 +1,97 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestBoundedCache(t *testing.T) {
+	c := newBoundedCache[string, int](2)
+
+	var calls int
+	build := func(v int) func() int {
+		return func() int {
+			calls++
+			return v
+		}
+	}
+
+	assert.Equal(t, 1, c.get("a", build(1)))
+	assert.Equal(t, 1, c.get("a", build(10)))
+	assert.Equal(t, 2, c.get("b", build(2)))
+	assert.Equal(t, 2, calls)
+
+	// The cache is full, new keys are computed on each call.
+	assert.Equal(t, 3, c.get("c", build(3)))
+	assert.Equal(t, 30, c.get("c", build(30)))
+	assert.Equal(t, 4, calls)
+	assert.Len(t, c.items, 2)
+}
+
+func TestMiddlewareCachedMetricAttributes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter))))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+		if c.Param("id") == "0" {
+			c.Writer().WriteHeader(http.StatusNotFound)
+		}
+	})
+	require.NoError(t, err)
+
+	for _, path := range []string{"/users/1", "/users/0", "/users/2", "/users/0"} {
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 2)
+
+	counts := make(map[int64]uint64)
+	for _, dp := range hist.DataPoints {
+		status, ok := dp.Attributes.Value("http.response.status_code")
+		require.True(t, ok)
+		counts[status.AsInt64()] = dp.Count
+	}
+	assert.Equal(t, map[int64]uint64{http.StatusOK: 2, http.StatusNotFound: 2}, counts)
+}
+
+func TestServerKey(t *testing.T) {
+	sc := semconv.NewServer(noop.Meter{})
+
+	request := func(method, host string) *http.Request {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Host = host
+		return req
+	}
+
+	// The host is not part of the key if the service name is known, and unknown methods are folded.
+	keys := make(map[semconv.ServerKey]struct{})
+	for i := range 100 {
+		keys[sc.ServerKey("foobar", request("METHOD"+strconv.Itoa(i), "host"+strconv.Itoa(i)+".example.com"))] = struct{}{}
+		keys[sc.ServerKey("foobar", request(http.MethodGet, "host"+strconv.Itoa(i)+".example.com:80"))] = struct{}{}
+	}
+	assert.Equal(t, map[semconv.ServerKey]struct{}{
+		{Method: "_OTHER", Port: -1}:       {},
+		{Method: http.MethodGet, Port: -1}: {},
+	}, keys)
+
+	assert.Equal(t,
+		semconv.ServerKey{Method: http.MethodGet, Host: "example.com", Port: 8080},
+		sc.ServerKey("", request(http.MethodGet, "example.com:8080")),
+	)
+}
@@ -0,0 +1,62 @@
+package oteltracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// overflowRoute is the "http.route" value of the attribute set into which the cardinality limiter folds measurements.
+const overflowRoute = "_OTHER"
+
+// cardinalityLimiter bounds the number of distinct attribute sets recorded by the request metrics. Once the limit is
+// reached, measurements with a new attribute set are folded into a single overflow attribute set, and counted by the
+// "oteltracing.metrics.cardinality.overflow" metric.
+type cardinalityLimiter struct {
+	overflow metric.Int64Counter
+	mu       sync.RWMutex
+	seen     map[attribute.Distinct]struct{}
+	limit    int
+}
+
+func newCardinalityLimiter(meter metric.Meter, limit int) *cardinalityLimiter {
+	overflow, err := meter.Int64Counter(
+		"oteltracing.metrics.cardinality.overflow",
+		metric.WithUnit("{measurement}"),
+		metric.WithDescription("Number of request measurements folded into the overflow attribute set because the cardinality limit was reached."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &cardinalityLimiter{
+		overflow: overflow,
+		seen:     make(map[attribute.Distinct]struct{}),
+		limit:    limit,
+	}
+}
+
+// allow reports whether the measurement with the attribute set d can be recorded as is. If not, the overflow is
+// counted and the caller must fold the measurement.
+func (l *cardinalityLimiter) allow(ctx context.Context, d attribute.Distinct) bool {
+	l.mu.RLock()
+	_, ok := l.seen[d]
+	l.mu.RUnlock()
+	if ok {
+		return true
+	}
+
+	l.mu.Lock()
+	if _, ok = l.seen[d]; !ok && len(l.seen) < l.limit {
+		l.seen[d] = struct{}{}
+		ok = true
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		l.overflow.Add(ctx, 1)
+	}
+	return ok
+}
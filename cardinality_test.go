@@ -0,0 +1,118 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWithMetricsCardinalityLimit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithMeterProvider(meter),
+			WithMetricsCardinalityLimit(2),
+			WithMetricsAttributes(func(c *fox.Context) []attribute.KeyValue {
+				return []attribute.KeyValue{attribute.String("user", c.Param("id"))}
+			}),
+		)),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/1", "/users/3", "/users/4", "/users/2"} {
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 3)
+	counts := make(map[string]uint64)
+	for _, dp := range hist.DataPoints {
+		route, ok := dp.Attributes.Value("http.route")
+		require.True(t, ok)
+		user, _ := dp.Attributes.Value("user")
+		counts[route.AsString()+" "+user.AsString()] = dp.Count
+	}
+	assert.Equal(t, map[string]uint64{
+		"/users/{id} 1": 2,
+		"/users/{id} 2": 2,
+		"_OTHER ":       2,
+	}, counts)
+
+	overflow := findMetric(t, rm, "oteltracing.metrics.cardinality.overflow").Data.(metricdata.Sum[int64])
+	require.Len(t, overflow.DataPoints, 1)
+	assert.Equal(t, int64(2), overflow.DataPoints[0].Value)
+}
+
+func TestCardinalityLimitDisabled(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter))))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		assert.NotEqual(t, "oteltracing.metrics.cardinality.overflow", m.Name)
+	}
+}
+
+func TestCardinalityLimitFoldsIntoSingleSet(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter), WithMetricsCardinalityLimit(1))))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/status/{code}", func(c *fox.Context) {
+		code, err := strconv.Atoi(c.Param("code"))
+		require.NoError(t, err)
+		c.Writer().WriteHeader(code)
+	})
+	require.NoError(t, err)
+
+	for code := 200; code < 260; code++ {
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/"+strconv.Itoa(code), nil))
+	}
+	// A different method is folded into the same attribute set.
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/status/500", nil))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 2)
+	var folded metricdata.HistogramDataPoint[float64]
+	for _, dp := range hist.DataPoints {
+		if route, _ := dp.Attributes.Value("http.route"); route.AsString() == overflowRoute {
+			folded = dp
+		}
+	}
+	assert.Equal(t, uint64(60), folded.Count)
+	assert.Equal(t, 1, folded.Attributes.Len())
+
+	overflow := findMetric(t, rm, "oteltracing.metrics.cardinality.overflow").Data.(metricdata.Sum[int64])
+	require.Len(t, overflow.DataPoints, 1)
+	assert.Equal(t, int64(60), overflow.DataPoints[0].Value)
+}
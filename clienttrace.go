@@ -0,0 +1,174 @@
+package oteltracing
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+	"go.opentelemetry.io/otel/attribute"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// clientTracer records the connection phases of an outgoing request as span events, and optionally the time spent
+// establishing new connections. The [httptrace.ClientTrace] hooks may be called concurrently (e.g. when dialing
+// multiple addresses), so the state is guarded by a mutex.
+type clientTracer struct {
+	ctx    context.Context
+	span   trace.Span
+	req    *http.Request
+	sc     semconv.Client
+	attrs  []attribute.KeyValue
+	events bool
+	record bool
+
+	mu        sync.Mutex
+	connStart time.Time
+}
+
+func newClientTracer(ctx context.Context, span trace.Span, req *http.Request, sc semconv.Client, events, record bool) *clientTracer {
+	var host string
+	if req.URL != nil {
+		host, _ = semconv.SplitHostPort(req.URL.Host)
+	}
+	return &clientTracer{
+		ctx:    ctx,
+		span:   span,
+		req:    req,
+		sc:     sc,
+		attrs:  sc.TraceAttributes(host),
+		events: events,
+		record: record,
+	}
+}
+
+// clientTrace returns the [httptrace.ClientTrace] hooks for this tracer.
+func (ct *clientTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:              ct.getConn,
+		GotConn:              ct.gotConn,
+		DNSStart:             ct.dnsStart,
+		DNSDone:              ct.dnsDone,
+		ConnectStart:         ct.connectStart,
+		ConnectDone:          ct.connectDone,
+		TLSHandshakeStart:    ct.tlsHandshakeStart,
+		TLSHandshakeDone:     ct.tlsHandshakeDone,
+		WroteRequest:         ct.wroteRequest,
+		GotFirstResponseByte: ct.gotFirstResponseByte,
+	}
+}
+
+func (ct *clientTracer) getConn(string) {
+	ct.addEvent("http.getconn")
+}
+
+func (ct *clientTracer) gotConn(info httptrace.GotConnInfo) {
+	var peer string
+	if info.Conn != nil {
+		peer = info.Conn.RemoteAddr().String()
+	}
+
+	ct.addEvent("http.gotconn",
+		attribute.Bool("http.conn.reused", info.Reused),
+		attribute.Bool("http.conn.wasidle", info.WasIdle),
+	)
+
+	if !ct.record || info.Reused {
+		return
+	}
+
+	ct.mu.Lock()
+	start := ct.connStart
+	ct.mu.Unlock()
+	if !start.IsZero() {
+		ct.sc.RecordConnectionSetupDuration(ct.ctx, float64(time.Since(start))/float64(time.Millisecond), ct.req, peer)
+	}
+}
+
+func (ct *clientTracer) dnsStart(info httptrace.DNSStartInfo) {
+	ct.markConnStart()
+	ct.addEvent("http.dns.start", otelsemconv.ServerAddress(info.Host))
+}
+
+func (ct *clientTracer) dnsDone(info httptrace.DNSDoneInfo) {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if len(info.Addrs) > 0 {
+		addrs := make([]string, 0, len(info.Addrs))
+		for _, addr := range info.Addrs {
+			addrs = append(addrs, addr.String())
+		}
+		attrs = append(attrs, attribute.StringSlice("http.dns.addrs", addrs))
+	}
+	if info.Err != nil {
+		attrs = append(attrs, attribute.String("http.dns.error", info.Err.Error()))
+	}
+	ct.addEvent("http.dns.done", attrs...)
+}
+
+func (ct *clientTracer) connectStart(network, addr string) {
+	ct.markConnStart()
+	ct.addEvent("http.connect.start",
+		attribute.String("network.transport", network),
+		attribute.String("network.peer.address", addr),
+	)
+}
+
+func (ct *clientTracer) connectDone(network, addr string, err error) {
+	attrs := make([]attribute.KeyValue, 0, 3)
+	attrs = append(attrs,
+		attribute.String("network.transport", network),
+		attribute.String("network.peer.address", addr),
+	)
+	if err != nil {
+		attrs = append(attrs, attribute.String("http.connect.error", err.Error()))
+	}
+	ct.addEvent("http.connect.done", attrs...)
+}
+
+func (ct *clientTracer) tlsHandshakeStart() {
+	ct.addEvent("http.tls.start")
+}
+
+func (ct *clientTracer) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	attrs := make([]attribute.KeyValue, 0, 3)
+	attrs = append(attrs, attribute.Bool("tls.resumed", state.DidResume))
+	if state.NegotiatedProtocol != "" {
+		attrs = append(attrs, attribute.String("tls.next_protocol", state.NegotiatedProtocol))
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("http.tls.error", err.Error()))
+	}
+	ct.addEvent("http.tls.done", attrs...)
+}
+
+func (ct *clientTracer) wroteRequest(info httptrace.WroteRequestInfo) {
+	if info.Err != nil {
+		ct.addEvent("http.send.done", attribute.String("http.send.error", info.Err.Error()))
+		return
+	}
+	ct.addEvent("http.send.done")
+}
+
+func (ct *clientTracer) gotFirstResponseByte() {
+	ct.addEvent("http.receive.start")
+}
+
+// markConnStart records the start of a new connection, on the first DNS lookup or dial.
+func (ct *clientTracer) markConnStart() {
+	ct.mu.Lock()
+	if ct.connStart.IsZero() {
+		ct.connStart = time.Now()
+	}
+	ct.mu.Unlock()
+}
+
+func (ct *clientTracer) addEvent(name string, attrs ...attribute.KeyValue) {
+	if !ct.events {
+		return
+	}
+	ct.span.AddEvent(name, trace.WithAttributes(append(attrs, ct.attrs...)...))
+}
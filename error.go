@@ -0,0 +1,158 @@
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fox-toolkit/fox"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestStateKey struct{}
+
+// requestState holds the per-request telemetry state that handlers can update through the error reporting API.
+// It is consumed by the [Middleware] once the handler returns. Handlers may report errors from multiple goroutines,
+// so the state is guarded by a mutex.
+type requestState struct {
+	mu          sync.Mutex
+	errorType   string
+	description string
+	forceError  bool
+}
+
+// setErrorType sets the error type, and if forceError is true, forces the span status to error with the provided
+// description.
+func (s *requestState) setErrorType(errorType string, forceError bool, description string) {
+	s.mu.Lock()
+	s.errorType = errorType
+	if forceError {
+		s.forceError = true
+		s.description = description
+	}
+	s.mu.Unlock()
+}
+
+// load returns the error type, whether the span status is forced to error, and the status description.
+func (s *requestState) load() (errorType string, forceError bool, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errorType, s.forceError, s.description
+}
+
+func stateFromContext(ctx context.Context) *requestState {
+	state, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return state
+}
+
+// ErrorOption configures how an error is recorded with [RecordError].
+type ErrorOption interface {
+	applyError(*errorConfig)
+}
+
+type errorOptionFunc func(*errorConfig)
+
+func (o errorOptionFunc) applyError(c *errorConfig) {
+	o(c)
+}
+
+type errorConfig struct {
+	errorType  string
+	attrs      []attribute.KeyValue
+	stackTrace bool
+	forceError bool
+}
+
+// WithErrorType overrides the "error.type" attribute recorded for the error. By default, the type of the error
+// (e.g. "*fs.PathError") is used. The value should have a low cardinality.
+func WithErrorType(errorType string) ErrorOption {
+	return errorOptionFunc(func(c *errorConfig) {
+		c.errorType = errorType
+	})
+}
+
+// WithErrorAttributes adds attributes to the exception event recorded for the error.
+func WithErrorAttributes(attrs ...attribute.KeyValue) ErrorOption {
+	return errorOptionFunc(func(c *errorConfig) {
+		c.attrs = append(c.attrs, attrs...)
+	})
+}
+
+// WithErrorStackTrace records the stack trace of the caller with the exception event.
+func WithErrorStackTrace() ErrorOption {
+	return errorOptionFunc(func(c *errorConfig) {
+		c.stackTrace = true
+	})
+}
+
+// WithErrorStatus forces the span status to [codes.Error], regardless of the response status code. By default,
+// the span status is derived from the response status code, and 4xx responses are not reported as errors.
+func WithErrorStatus() ErrorOption {
+	return errorOptionFunc(func(c *errorConfig) {
+		c.forceError = true
+	})
+}
+
+// RecordError records err as an exception event on the server span created by [Middleware] and sets the
+// "error.type" attribute on both the span and the "http.server.request.duration" metric. This function is a no-op
+// if err is nil. If the request is not instrumented by [Middleware], the error is recorded on the span found in the
+// request context, if any.
+func RecordError(c *fox.Context, err error, opts ...ErrorOption) {
+	if err == nil {
+		return
+	}
+
+	cfg := new(errorConfig)
+	for _, opt := range opts {
+		opt.applyError(cfg)
+	}
+
+	ctx := c.Request().Context()
+	span := trace.SpanFromContext(ctx)
+
+	eventOpts := make([]trace.EventOption, 0, 2)
+	if len(cfg.attrs) > 0 {
+		eventOpts = append(eventOpts, trace.WithAttributes(cfg.attrs...))
+	}
+	if cfg.stackTrace {
+		eventOpts = append(eventOpts, trace.WithStackTrace(true))
+	}
+	span.RecordError(err, eventOpts...)
+
+	errorType := cfg.errorType
+	if errorType == "" {
+		errorType = fmt.Sprintf("%T", err)
+	}
+
+	if state := stateFromContext(ctx); state != nil {
+		var description string
+		if cfg.forceError {
+			description = err.Error()
+		}
+		state.setErrorType(errorType, cfg.forceError, description)
+		return
+	}
+
+	span.SetAttributes(otelsemconv.ErrorTypeKey.String(errorType))
+	if cfg.forceError {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// SetErrorType sets the "error.type" attribute on the server span created by [Middleware] and on the
+// "http.server.request.duration" metric, without recording an exception event. The value should have a low
+// cardinality. An empty errorType clears any previously set value.
+func SetErrorType(c *fox.Context, errorType string) {
+	ctx := c.Request().Context()
+	if state := stateFromContext(ctx); state != nil {
+		state.setErrorType(errorType, false, "")
+		return
+	}
+
+	if errorType != "" {
+		trace.SpanFromContext(ctx).SetAttributes(otelsemconv.ErrorTypeKey.String(errorType))
+	}
+}
@@ -0,0 +1,141 @@
+package oteltracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecordError(t *testing.T) {
+	errNotFound := errors.New("user not found")
+
+	cases := []struct {
+		name          string
+		handler       fox.HandlerFunc
+		wantCode      codes.Code
+		wantErrorType string
+		wantEvents    int
+	}{
+		{
+			name: "record error with default type",
+			handler: func(c *fox.Context) {
+				RecordError(c, errNotFound)
+				_ = c.String(http.StatusNotFound, "not found")
+			},
+			wantCode:      codes.Unset,
+			wantErrorType: "*errors.errorString",
+			wantEvents:    1,
+		},
+		{
+			name: "record error with custom type and forced status",
+			handler: func(c *fox.Context) {
+				RecordError(c, errNotFound, WithErrorType("user_not_found"), WithErrorStatus())
+				_ = c.String(http.StatusNotFound, "not found")
+			},
+			wantCode:      codes.Error,
+			wantErrorType: "user_not_found",
+			wantEvents:    1,
+		},
+		{
+			name: "set error type only",
+			handler: func(c *fox.Context) {
+				SetErrorType(c, "validation")
+				_ = c.String(http.StatusBadRequest, "bad request")
+			},
+			wantCode:      codes.Unset,
+			wantErrorType: "validation",
+		},
+		{
+			name: "nil error is a no-op",
+			handler: func(c *fox.Context) {
+				RecordError(c, nil)
+				_ = c.String(http.StatusOK, "ok")
+			},
+			wantCode: codes.Unset,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter))),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/users/{id}", tc.handler)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tc.wantCode, span.Status().Code)
+			assert.Len(t, span.Events(), tc.wantEvents)
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			dpErrorType, _ := hist.DataPoints[0].Attributes.Value("error.type")
+
+			if tc.wantErrorType != "" {
+				assert.Contains(t, span.Attributes(), attribute.String("error.type", tc.wantErrorType))
+				assert.Equal(t, tc.wantErrorType, dpErrorType.AsString())
+				return
+			}
+			for _, attr := range span.Attributes() {
+				assert.NotEqual(t, attribute.Key("error.type"), attr.Key)
+			}
+			assert.Empty(t, dpErrorType.AsString())
+		})
+	}
+}
+
+func TestRecordErrorConcurrently(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider))))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/fanout", func(c *fox.Context) {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				RecordError(c, errors.New("upstream failure"), WithErrorType("upstream"), WithErrorStatus())
+				SetErrorType(c, "upstream")
+			}()
+		}
+		wg.Wait()
+	})
+	require.NoError(t, err)
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fanout", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "upstream failure", spans[0].Status().Description)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error.type", "upstream"))
+}
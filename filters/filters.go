@@ -0,0 +1,186 @@
+// Package filters provides a set of composable [oteltracing.Filter] constructors for common exclusion rules. Every
+// filter returns true if the request matches, so exclusions are expressed with [Not]:
+//
+//	oteltracing.Middleware("fox", oteltracing.WithFilter(
+//		filters.Not(filters.Any(filters.Route("/healthz"), filters.HealthCheckUserAgent())),
+//	))
+//
+// The filters may also be used with [oteltracing.WithTraceFilter] or [oteltracing.WithMetricFilter], e.g. to record
+// the metrics of health checks without tracing them.
+package filters
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/oteltracing"
+)
+
+// healthCheckUserAgents are the user agent prefixes of common health checkers and probes.
+var healthCheckUserAgents = []string{
+	"kube-probe/",
+	"ELB-HealthChecker/",
+	"GoogleHC/",
+	"Consul Health Check",
+	"Envoy/HC",
+	"Amazon-Route53-Health-Check-Service",
+	"AlwaysOn",
+}
+
+// All returns a filter that matches if all the provided filters match. It matches if no filter is provided.
+func All(filters ...oteltracing.Filter) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		for _, f := range filters {
+			if !f(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any returns a filter that matches if any of the provided filters match. It does not match if no filter is provided.
+func Any(filters ...oteltracing.Filter) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		for _, f := range filters {
+			if f(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter that matches if the provided filter does not match.
+func Not(f oteltracing.Filter) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		return !f(c)
+	}
+}
+
+// Route returns a filter that matches if the route pattern of the request is one of the provided patterns
+// (e.g. "/users/{id}"). Requests that are not handled by a route handler have an empty pattern.
+func Route(patterns ...string) oteltracing.Filter {
+	set := make(map[string]struct{}, len(patterns))
+	for _, p := range patterns {
+		set[p] = struct{}{}
+	}
+	return func(c *fox.Context) bool {
+		_, ok := set[c.Pattern()]
+		return ok
+	}
+}
+
+// RoutePrefix returns a filter that matches if the route pattern of the request starts with the provided prefix.
+func RoutePrefix(prefix string) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		pattern := c.Pattern()
+		return pattern != "" && strings.HasPrefix(pattern, prefix)
+	}
+}
+
+// RouteGlob returns a filter that matches if the route pattern of the request matches the provided glob, with the
+// syntax of [path.Match] (e.g. "/internal/*" or "/static/*/*"). Note that "*" does not match the "/" separator.
+// RouteGlob panics if the glob is malformed.
+func RouteGlob(glob string) oteltracing.Filter {
+	if _, err := path.Match(glob, ""); err != nil {
+		panic(err)
+	}
+	return func(c *fox.Context) bool {
+		pattern := c.Pattern()
+		if pattern == "" {
+			return false
+		}
+		ok, _ := path.Match(glob, pattern)
+		return ok
+	}
+}
+
+// Path returns a filter that matches if the URL path of the request is one of the provided paths.
+func Path(paths ...string) oteltracing.Filter {
+	set := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		set[p] = struct{}{}
+	}
+	return func(c *fox.Context) bool {
+		_, ok := set[c.Path()]
+		return ok
+	}
+}
+
+// PathPrefix returns a filter that matches if the URL path of the request starts with the provided prefix.
+func PathPrefix(prefix string) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		return strings.HasPrefix(c.Path(), prefix)
+	}
+}
+
+// Method returns a filter that matches if the request method is one of the provided methods. The comparison is
+// case-sensitive, like HTTP methods.
+func Method(methods ...string) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		method := c.Method()
+		for _, m := range methods {
+			if m == method {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HeaderExists returns a filter that matches if the request has the provided header.
+func HeaderExists(name string) oteltracing.Filter {
+	name = http.CanonicalHeaderKey(name)
+	return func(c *fox.Context) bool {
+		_, ok := c.Request().Header[name]
+		return ok
+	}
+}
+
+// Header returns a filter that matches if any value of the provided request header is equal to value.
+func Header(name, value string) oteltracing.Filter {
+	name = http.CanonicalHeaderKey(name)
+	return func(c *fox.Context) bool {
+		for _, v := range c.Request().Header[name] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// UserAgentPrefix returns a filter that matches if the user agent of the request starts with any of the provided
+// prefixes.
+func UserAgentPrefix(prefixes ...string) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		ua := c.Request().UserAgent()
+		if ua == "" {
+			return false
+		}
+		for _, p := range prefixes {
+			if strings.HasPrefix(ua, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HealthCheckUserAgent returns a filter that matches requests sent by common health checkers and probes, such as
+// Kubernetes probes, AWS ELB and Route 53, Google Cloud load balancers, Azure, Consul and Envoy, based on their user
+// agent.
+func HealthCheckUserAgent() oteltracing.Filter {
+	return UserAgentPrefix(healthCheckUserAgents...)
+}
+
+// Scope returns a filter that matches if the request is handled in one of the provided scopes, which may be combined
+// with the bitwise OR operator (e.g. fox.NoRouteHandler|fox.NoMethodHandler).
+func Scope(scopes fox.HandlerScope) oteltracing.Filter {
+	return func(c *fox.Context) bool {
+		return c.Scope()&scopes != 0
+	}
+}
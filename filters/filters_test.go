@@ -0,0 +1,127 @@
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/oteltracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// match serves the request with a router using a middleware that records the result of the filter.
+func match(t *testing.T, f oteltracing.Filter, req *http.Request) bool {
+	t.Helper()
+
+	var matched bool
+	r, err := fox.NewRouter(
+		fox.WithMiddleware(func(next fox.HandlerFunc) fox.HandlerFunc {
+			return func(c *fox.Context) {
+				matched = f(c)
+				next(c)
+			}
+		}),
+		fox.WithNoMethod(true),
+	)
+	require.NoError(t, err)
+	for _, pattern := range []string{"/users/{id}", "/static/css/{file}", "/healthz"} {
+		_, err = r.Add(fox.MethodGet, pattern, func(c *fox.Context) {})
+		require.NoError(t, err)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return matched
+}
+
+func TestFilters(t *testing.T) {
+	healthz := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	probe := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.30")
+	css := httptest.NewRequest(http.MethodGet, "/static/css/main.css", nil)
+	css.Header.Set("X-Synthetic", "true")
+	notFound := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	noMethod := httptest.NewRequest(http.MethodPost, "/healthz", nil)
+
+	cases := []struct {
+		name   string
+		filter oteltracing.Filter
+		req    *http.Request
+		want   bool
+	}{
+		{name: "route", filter: Route("/healthz"), req: healthz, want: true},
+		{name: "route with multiple patterns", filter: Route("/foo", "/users/{id}"), req: probe, want: true},
+		{name: "route no match", filter: Route("/healthz"), req: probe, want: false},
+		{name: "route without pattern", filter: Route(""), req: notFound, want: true},
+		{name: "route prefix", filter: RoutePrefix("/static/"), req: css, want: true},
+		{name: "route prefix no match", filter: RoutePrefix("/static/"), req: healthz, want: false},
+		{name: "route prefix without pattern", filter: RoutePrefix(""), req: notFound, want: false},
+		{name: "route glob", filter: RouteGlob("/static/*/*"), req: css, want: true},
+		{name: "route glob does not cross separator", filter: RouteGlob("/static/*"), req: css, want: false},
+		{name: "path", filter: Path("/users/123"), req: probe, want: true},
+		{name: "path no match", filter: Path("/users/{id}"), req: probe, want: false},
+		{name: "path prefix", filter: PathPrefix("/static"), req: css, want: true},
+		{name: "method", filter: Method(http.MethodPost, http.MethodGet), req: healthz, want: true},
+		{name: "method no match", filter: Method(http.MethodPost), req: healthz, want: false},
+		{name: "header exists", filter: HeaderExists("x-synthetic"), req: css, want: true},
+		{name: "header exists no match", filter: HeaderExists("x-synthetic"), req: healthz, want: false},
+		{name: "header value", filter: Header("X-Synthetic", "true"), req: css, want: true},
+		{name: "header value no match", filter: Header("X-Synthetic", "false"), req: css, want: false},
+		{name: "user agent prefix", filter: UserAgentPrefix("curl/", "kube-probe/"), req: probe, want: true},
+		{name: "health check user agent", filter: HealthCheckUserAgent(), req: probe, want: true},
+		{name: "health check user agent no match", filter: HealthCheckUserAgent(), req: healthz, want: false},
+		{name: "scope", filter: Scope(fox.NoRouteHandler | fox.NoMethodHandler), req: notFound, want: true},
+		{name: "scope no method", filter: Scope(fox.NoRouteHandler | fox.NoMethodHandler), req: noMethod, want: true},
+		{name: "scope no match", filter: Scope(fox.NoRouteHandler), req: healthz, want: false},
+		{name: "all", filter: All(Route("/users/{id}"), HealthCheckUserAgent()), req: probe, want: true},
+		{name: "all no match", filter: All(Route("/users/{id}"), Method(http.MethodPost)), req: probe, want: false},
+		{name: "all empty", filter: All(), req: probe, want: true},
+		{name: "any", filter: Any(Route("/healthz"), HealthCheckUserAgent()), req: probe, want: true},
+		{name: "any no match", filter: Any(Route("/healthz"), Method(http.MethodPost)), req: probe, want: false},
+		{name: "any empty", filter: Any(), req: probe, want: false},
+		{name: "not", filter: Not(Route("/healthz")), req: healthz, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, match(t, tc.filter, tc.req))
+		})
+	}
+}
+
+func TestRouteGlobMalformed(t *testing.T) {
+	assert.Panics(t, func() {
+		RouteGlob("/static/[")
+	})
+}
+
+func TestWithMiddlewareFilter(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(oteltracing.Middleware(
+			"foobar",
+			oteltracing.WithTracerProvider(provider),
+			oteltracing.WithFilter(Not(Any(Route("/healthz"), HealthCheckUserAgent()))),
+		)),
+	)
+	require.NoError(t, err)
+	for _, pattern := range []string{"/healthz", "/users/{id}"} {
+		_, err = f.Add(fox.MethodGet, pattern, func(c *fox.Context) {})
+		require.NoError(t, err)
+	}
+
+	probe := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.30")
+	for _, req := range []*http.Request{httptest.NewRequest(http.MethodGet, "/healthz", nil), probe, httptest.NewRequest(http.MethodGet, "/users/123", nil)} {
+		f.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /users/{id}", spans[0].Name())
+}
@@ -1,15 +1,23 @@
 package oteltracing
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/fox-toolkit/fox"
 	"github.com/fox-toolkit/oteltracing/internal/clientip"
 	"github.com/fox-toolkit/oteltracing/internal/semconv"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
 	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 const (
@@ -17,6 +25,17 @@ const (
 	ScopeName = "github.com/fox-toolkit/oteltracing"
 )
 
+const (
+	// errorTypeClientDisconnected is the "error.type" value recorded when the client closes the connection before
+	// the handler returns.
+	errorTypeClientDisconnected = "client_disconnected"
+	// errorTypeWriteError is the "error.type" value recorded when writing the response to the client fails.
+	errorTypeWriteError = "write_error"
+)
+
+// serverSpanKind is allocated once, as it is shared by every server span.
+var serverSpanKind = oteltrace.WithSpanKind(oteltrace.SpanKindServer)
+
 var (
 	// DefaultClientIPResolver attempts to resolve client IP addresses in the following order:
 	// 1. Leftmost non-private IP in X-Forwarded-For header
@@ -50,7 +69,25 @@ func Middleware(service string, opts ...Option) fox.MiddlewareFunc {
 	tracer := cfg.provider.Tracer(ScopeName, oteltrace.WithInstrumentationVersion(Version))
 	meter := cfg.meter.Meter(ScopeName, metric.WithInstrumentationVersion(Version))
 
-	sc := semconv.NewHTTPServer(meter)
+	serverOpts := []semconv.ServerOption{
+		semconv.WithMode(cfg.semconv.mode()),
+		semconv.WithRequestSizeBuckets(cfg.buckets.requestSize),
+		semconv.WithResponseSizeBuckets(cfg.buckets.responseSize),
+	}
+	if len(cfg.buckets.duration) > 0 {
+		serverOpts = append(serverOpts, semconv.WithDurationBuckets(cfg.buckets.duration))
+	}
+	sc := semconv.NewServer(meter, serverOpts...)
+	metricOpts := newBoundedCache[metricKey, semconv.ServerMetricOption](maxCachedAttributeSets)
+	activeOpts := newBoundedCache[activeKey, metric.MeasurementOption](maxCachedAttributeSets)
+	var (
+		limiter     *cardinalityLimiter
+		overflowOpt semconv.ServerMetricOption
+	)
+	if cfg.cardinality > 0 {
+		limiter = newCardinalityLimiter(meter, cfg.cardinality)
+		overflowOpt = sc.FixedMetricOption(sc.Route(overflowRoute))
+	}
 
 	return func(next fox.HandlerFunc) fox.HandlerFunc {
 		return func(c *fox.Context) {
@@ -58,74 +95,328 @@ func Middleware(service string, opts ...Option) fox.MiddlewareFunc {
 
 			req := c.Request()
 
-			for _, f := range cfg.filters {
-				if !f(c) {
-					next(c)
-					return
-				}
+			if !allowed(c, cfg.filters) {
+				next(c)
+				return
+			}
+
+			scope := c.Scope()
+			traced, metered := scope&cfg.skipTrace == 0, scope&cfg.skipMetrics == 0
+			rc := routeConfigFrom(c)
+			if rc != nil {
+				traced, metered = traced && !rc.disableTracing, metered && !rc.disableMetrics
+			}
+			traced = traced && allowed(c, cfg.traceFilters)
+			metered = metered && allowed(c, cfg.metricFilters)
+			if !traced && !metered {
+				next(c)
+				return
 			}
 
+			w := c.Writer()
 			defer func() {
-				// rollback to the original request
+				// rollback to the original request and writer
 				c.SetRequest(req)
+				c.SetWriter(w)
 			}()
 
-			ctx := cfg.propagator.Extract(req.Context(), cfg.carrier(req))
-			requestTraceAttrOpts := semconv.RequestTraceAttrsOpts{
-				HTTPClientIP: serverClientIP(c, cfg.resolver),
+			// The baggage of public endpoints comes from untrusted callers, so it is never promoted to attributes.
+			public := cfg.publicFn != nil && cfg.publicFn(c)
+
+			ctx := req.Context()
+			var span oteltrace.Span = noop.Span{}
+			if traced {
+				ctx = cfg.propagator.Extract(ctx, cfg.carrier(req))
+				opts := spanStartOptions(ctx, c, cfg, sc, service, rc, public)
+
+				spanName := cfg.spanFmt(c)
+				if rc != nil && rc.spanName != "" {
+					spanName = rc.spanName
+				}
+				if spanName == "" {
+					method := spanMethod(req.Method)
+					if spanName = scopeSpanName(method, c.Scope()); spanName == "" {
+						spanName = method
+					}
+				}
+
+				ctx, span = tracer.Start(ctx, spanName, opts...)
 			}
 
-			opts := []oteltrace.SpanStartOption{
-				oteltrace.WithAttributes(sc.RequestTraceAttrs(service, req, requestTraceAttrOpts)...),
-				oteltrace.WithAttributes(sc.Route(c.Pattern())),
-				oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+			var baggageAttrs []attribute.KeyValue
+			if metered && !public && cfg.baggage != nil && len(cfg.baggage.metrics) > 0 {
+				// The propagator only extracts the baggage of traced requests.
+				bagCtx := ctx
+				if !traced {
+					bagCtx = cfg.propagator.Extract(ctx, cfg.carrier(req))
+				}
+				baggageAttrs = cfg.baggage.attributes(baggage.FromContext(bagCtx), cfg.baggage.metrics)
 			}
 
-			opts = append(opts, cfg.spanOpts...)
+			state := new(requestState)
+			ctx = context.WithValue(ctx, requestStateKey{}, state)
 
-			spanName := cfg.spanFmt(c)
-			if spanName == "" {
-				spanName = fmt.Sprintf("HTTP %s route not found", req.Method)
+			// pass the span through the request context
+			r := req.WithContext(ctx)
+			var body *bodyWrapper
+			if req.Body != nil && req.Body != http.NoBody {
+				body = newBodyWrapper(req.Body, span)
+				r.Body = body
 			}
+			c.SetRequest(r)
+			rw := newResponseWriter(w)
+			c.SetWriter(rw)
 
-			ctx, span := tracer.Start(ctx, spanName, opts...)
-			defer span.End()
+			if traced {
+				for _, p := range cfg.respProps {
+					p.Inject(ctx, rw.Header())
+				}
+			}
 
-			// pass the span through the request context
-			c.SetRequest(req.WithContext(ctx))
+			// The metrics are recorded within the span context, so that the SDK can attach exemplars linked to the
+			// request trace.
+			metricCtx := exemplarContext(ctx, span, cfg.traceExemplars)
+
+			var (
+				activeRequestsOpt metric.MeasurementOption
+				serverKey         semconv.ServerKey
+			)
+			if metered {
+				serverKey = sc.ServerKey(service, req)
+				var activeRequestsRoute string
+				if cfg.activeRoute {
+					activeRequestsRoute = c.Pattern()
+				}
+				key := activeKey{
+					server: serverKey,
+					route:  activeRequestsRoute,
+				}
+				activeRequestsOpt = activeOpts.get(key, func() metric.MeasurementOption {
+					return sc.ActiveRequestsOption(service, req, activeRequestsRoute)
+				})
+				sc.AddActiveRequests(metricCtx, 1, activeRequestsOpt)
+			}
+
+			defer func() {
+				// The span and metrics are recorded in a deferred call so that a panicking handler is still
+				// accounted for. The stack trace is captured here, before the stack unwinds. The span is ended
+				// explicitly, so that the SDK does not record the panic a second time.
+				recovered := recover()
+
+				if metered {
+					sc.AddActiveRequests(metricCtx, -1, activeRequestsOpt)
+				}
+
+				var (
+					readBytes int64
+					readErr   error
+				)
+				if body != nil {
+					readBytes = body.BytesRead()
+					readErr = body.ReadError()
+				}
+
+				writeErr := rw.WriteError()
+				if writeErr != nil {
+					span.RecordError(writeErr)
+				}
+
+				status := rw.Status()
+				errorType, forceError, description := state.load()
+				if recovered != nil {
+					status = http.StatusInternalServerError
+					errorType = fmt.Sprintf("%T", recovered)
+					span.RecordError(panicError(recovered), oteltrace.WithStackTrace(true))
+				} else if errorType == "" {
+					// The request context of the server is only canceled before the handler returns if the
+					// client's connection closes.
+					if errors.Is(req.Context().Err(), context.Canceled) {
+						errorType = errorTypeClientDisconnected
+					} else if writeErr != nil {
+						errorType = errorTypeWriteError
+					}
+				}
+
+				if traced {
+					span.SetAttributes(sc.ResponseTraceAttrs(semconv.ResponseTelemetry{
+						StatusCode: status,
+						ReadBytes:  readBytes,
+						ReadError:  readErr,
+						WriteBytes: int64(rw.Size()),
+						WriteError: writeErr,
+					})...)
+					if errorType != "" {
+						span.SetAttributes(otelsemconv.ErrorTypeKey.String(errorType))
+					}
+					if attrs := headerAttributes(cfg.resHeaders, rw.Header(), cfg.redactor); len(attrs) > 0 {
+						span.SetAttributes(attrs...)
+					}
+
+					statusFn := cfg.statusFn
+					if rc != nil && rc.statusFn != nil {
+						statusFn = rc.statusFn
+					}
+
+					switch {
+					case recovered != nil:
+						span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", recovered))
+					case forceError:
+						span.SetStatus(codes.Error, description)
+					default:
+						span.SetStatus(statusFn(c, status))
+					}
+				}
+
+				if metered {
+					// Record the server-side attributes. The measurement option is cached per route, method, status
+					// and server attributes, unless the request carries attributes from the MetricAttributesFunc. The
+					// attributes are derived from the incoming request, since the handler may have replaced it.
+					key := metricKey{
+						rc:        rc,
+						server:    serverKey,
+						route:     c.Pattern(),
+						proto:     req.Proto,
+						errorType: errorType,
+						status:    status,
+					}
+					userAttrs := cfg.attrsFn(c)
+					if len(baggageAttrs) > 0 {
+						userAttrs = append(slices.Clip(userAttrs), baggageAttrs...)
+					}
+					o := metricOption(sc, service, metricOpts, key, req, userAttrs)
+					if limiter != nil && !limiter.allow(metricCtx, o.Equivalent()) {
+						// Fold the measurement into a single attribute set, so that the overflow does not add
+						// series of its own.
+						o = overflowOpt
+					}
+
+					sc.RecordMetricsWithOption(metricCtx, semconv.MetricData{
+						RequestSize: readBytes,
+						ElapsedTime: float64(time.Since(requestStartTime)) / float64(time.Millisecond),
+					}, int64(rw.Size()), o)
+				}
+
+				span.End()
+
+				if recovered != nil {
+					if cfg.repanic || isAbortHandler(recovered) {
+						panic(recovered)
+					}
+					if !rw.Written() {
+						http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					}
+				}
+			}()
 
 			next(c)
+		}
+	}
+}
 
-			status := c.Writer().Status()
-			span.SetStatus(sc.Status(status))
-			span.SetAttributes(sc.ResponseTraceAttrs(semconv.ResponseTelemetry{
-				StatusCode: status,
-				WriteBytes: int64(c.Writer().Size()),
-			})...)
-
-			// Record the server-side attributes.
-			var additionalAttributes []attribute.KeyValue
-			if pattern := c.Pattern(); pattern != "" {
-				additionalAttributes = []attribute.KeyValue{sc.Route(pattern)}
-			}
-			if cfg.attrsFn != nil {
-				additionalAttributes = append(additionalAttributes, cfg.attrsFn(c)...)
-			}
-			sc.RecordMetrics(ctx, semconv.ServerMetricData{
-				ServerName:   service,
-				ResponseSize: int64(c.Writer().Size()),
-				MetricAttributes: semconv.MetricAttributes{
-					Req:                  c.Request(),
-					StatusCode:           status,
-					AdditionalAttributes: additionalAttributes,
-				},
-				MetricData: semconv.MetricData{
-					RequestSize: c.Request().ContentLength,
-					ElapsedTime: float64(time.Since(requestStartTime)) / float64(time.Millisecond),
-				},
-			})
+// spanStartOptions returns the options used to start the server span. The ctx must hold the span context and the
+// baggage extracted from the request, if any. If public is true, the request is handled by a public endpoint.
+func spanStartOptions(ctx context.Context, c *fox.Context, cfg *config, sc semconv.Server, service string, rc *routeConfig, public bool) []oteltrace.SpanStartOption {
+	req := c.Request()
+	requestTraceAttrOpts := semconv.RequestTraceAttrsOpts{
+		HTTPClientIP: serverClientIP(c, cfg.resolver),
+	}
+
+	var query string
+	if req.URL != nil {
+		query = redactQuery(cfg.redactor, req.URL.RawQuery)
+	}
+
+	// The request attributes are gathered in a single slice to limit the allocations of the span start options.
+	attrs := append(
+		sc.RequestTraceAttrs(service, req, requestTraceAttrOpts, query),
+		sc.Route(c.Pattern()),
+		HandlerScopeKey.String(scopeName(c.Scope())),
+	)
+	attrs = append(attrs, headerAttributes(cfg.reqHeaders, req.Header, cfg.redactor)...)
+	if cfg.params != nil && cfg.params.enabled {
+		attrs = append(attrs, cfg.params.attributes(c)...)
+	}
+	if cfg.baggage != nil && !public {
+		attrs = append(attrs, cfg.baggage.attributes(baggage.FromContext(ctx), cfg.baggage.span)...)
+	}
+	if rc != nil {
+		attrs = append(attrs, rc.spanAttrs...)
+	}
+
+	opts := make([]oteltrace.SpanStartOption, 0, len(cfg.spanOpts)+4)
+	opts = append(opts, oteltrace.WithAttributes(attrs...), serverSpanKind)
+
+	if public {
+		opts = append(opts, oteltrace.WithNewRoot())
+		// Linking incoming span context if any for public endpoint.
+		if s := oteltrace.SpanContextFromContext(ctx); s.IsValid() && s.IsRemote() {
+			opts = append(opts, oteltrace.WithLinks(oteltrace.Link{SpanContext: s}))
+		}
+	}
+
+	return append(opts, cfg.spanOpts...)
+}
+
+// allowed reports whether all the filters allow the request.
+func allowed(c *fox.Context, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// metricOption returns the measurement options of the request metrics. The options are cached, unless the request
+// carries attributes from the MetricAttributesFunc.
+func metricOption(sc semconv.Server, service string, cache *boundedCache[metricKey, semconv.ServerMetricOption], key metricKey, req *http.Request, userAttrs []attribute.KeyValue) semconv.ServerMetricOption {
+	build := func() semconv.ServerMetricOption {
+		var additionalAttributes []attribute.KeyValue
+		if key.route != "" {
+			additionalAttributes = []attribute.KeyValue{sc.Route(key.route)}
+		}
+		additionalAttributes = append(additionalAttributes, userAttrs...)
+		if key.rc != nil {
+			additionalAttributes = append(additionalAttributes, key.rc.metricAttrs...)
+		}
+		if key.errorType != "" {
+			additionalAttributes = append(additionalAttributes, otelsemconv.ErrorTypeKey.String(key.errorType))
 		}
+		return sc.MetricOption(service, semconv.MetricAttributes{
+			Req:                  req,
+			StatusCode:           key.status,
+			AdditionalAttributes: additionalAttributes,
+		})
 	}
+
+	if len(userAttrs) > 0 {
+		return build()
+	}
+	return cache.get(key, build)
+}
+
+// exemplarContext returns the context used to record the metrics of a request. If traceBased is true and the span is
+// not sampled, the span context is removed, so that no exemplar links to a trace that is not exported.
+func exemplarContext(ctx context.Context, span oteltrace.Span, traceBased bool) context.Context {
+	if traceBased && !span.SpanContext().IsSampled() {
+		return oteltrace.ContextWithSpanContext(ctx, oteltrace.SpanContext{})
+	}
+	return ctx
+}
+
+// panicError converts a recovered panic value into an error.
+func panicError(recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", recovered)
+}
+
+// isAbortHandler reports whether the recovered value is [http.ErrAbortHandler], which must always be
+// propagated to the server to abort the response.
+func isAbortHandler(recovered any) bool {
+	err, ok := recovered.(error)
+	return ok && errors.Is(err, http.ErrAbortHandler)
 }
 
 func serverClientIP(c *fox.Context, resolver fox.ClientIPResolver) string {
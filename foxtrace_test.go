@@ -2,8 +2,10 @@ package oteltracing
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/fox-toolkit/fox"
@@ -11,7 +13,14 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 
@@ -165,3 +174,443 @@ func TestWithMetricAttributes(t *testing.T) {
 
 	f.ServeHTTP(w, r)
 }
+
+func TestPanicIsRecorded(t *testing.T) {
+	cases := []struct {
+		name    string
+		repanic bool
+	}{
+		{name: "with repanic", repanic: true},
+		{name: "without repanic", repanic: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			recovered := false
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(
+					func(next fox.HandlerFunc) fox.HandlerFunc {
+						return func(c *fox.Context) {
+							defer func() {
+								if rec := recover(); rec != nil {
+									recovered = true
+									c.Writer().WriteHeader(http.StatusInternalServerError)
+								}
+							}()
+							next(c)
+						}
+					},
+					Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter), WithRepanic(tc.repanic)),
+				),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/panic", func(c *fox.Context) {
+				panic(errors.New("boom"))
+			})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.repanic, recovered)
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, codes.Error, span.Status().Code)
+			assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+			assert.Contains(t, span.Attributes(), attribute.String("error.type", "*errors.errorString"))
+			require.Len(t, span.Events(), 1)
+			assert.Equal(t, "exception", span.Events()[0].Name)
+			assert.Contains(t, span.Events()[0].Attributes, attribute.String("exception.message", "boom"))
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			duration := findMetric(t, rm, "http.server.request.duration")
+			hist := duration.Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			assert.Contains(t, hist.DataPoints[0].Attributes.ToSlice(), attribute.String("error.type", "*errors.errorString"))
+			assert.Contains(t, hist.DataPoints[0].Attributes.ToSlice(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+		})
+	}
+}
+
+func findMetric(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	require.Failf(t, "metric not found", "no metric named %q", name)
+	return metricdata.Metrics{}
+}
+
+func TestActiveRequests(t *testing.T) {
+	cases := []struct {
+		name      string
+		opts      []Option
+		wantRoute bool
+	}{
+		{name: "without route"},
+		{name: "with route", opts: []Option{WithActiveRequestsRoute()}, wantRoute: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			collect := func() metricdata.DataPoint[int64] {
+				var rm metricdata.ResourceMetrics
+				require.NoError(t, reader.Collect(context.Background(), &rm))
+				sum := findMetric(t, rm, "http.server.active_requests").Data.(metricdata.Sum[int64])
+				require.Len(t, sum.DataPoints, 1)
+				return sum.DataPoints[0]
+			}
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithMeterProvider(meter))...)),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+				dp := collect()
+				assert.Equal(t, int64(1), dp.Value)
+				route, ok := dp.Attributes.Value("http.route")
+				assert.Equal(t, tc.wantRoute, ok)
+				if tc.wantRoute {
+					assert.Equal(t, "/users/{id}", route.AsString())
+				}
+				method, _ := dp.Attributes.Value("http.request.method")
+				assert.Equal(t, http.MethodGet, method.AsString())
+				_ = c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			assert.Equal(t, int64(0), collect().Value)
+		})
+	}
+}
+
+func TestWithPublicEndpoint(t *testing.T) {
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	prop := propagation.TraceContext{}
+
+	cases := []struct {
+		name       string
+		opts       []Option
+		path       string
+		wantPublic bool
+	}{
+		{name: "public endpoint", opts: []Option{WithPublicEndpoint()}, path: "/public", wantPublic: true},
+		{name: "private endpoint", path: "/public", wantPublic: false},
+		{
+			name: "public endpoint fn match",
+			opts: []Option{WithPublicEndpointFn(func(c *fox.Context) bool {
+				return c.Pattern() == "/public"
+			})},
+			path:       "/public",
+			wantPublic: true,
+		},
+		{
+			name: "public endpoint fn no match",
+			opts: []Option{WithPublicEndpointFn(func(c *fox.Context) bool {
+				return c.Pattern() == "/public"
+			})},
+			path:       "/private",
+			wantPublic: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithPropagators(prop))...)),
+			)
+			require.NoError(t, err)
+			for _, path := range []string{"/public", "/private"} {
+				_, err = f.Add(fox.MethodGet, path, func(c *fox.Context) {})
+				require.NoError(t, err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			prop.Inject(trace.ContextWithRemoteSpanContext(context.Background(), remote), propagation.HeaderCarrier(r.Header))
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			if tc.wantPublic {
+				assert.NotEqual(t, remote.TraceID(), span.SpanContext().TraceID())
+				assert.False(t, span.Parent().IsValid())
+				require.Len(t, span.Links(), 1)
+				assert.Equal(t, remote.TraceID(), span.Links()[0].SpanContext.TraceID())
+				assert.Equal(t, remote.SpanID(), span.Links()[0].SpanContext.SpanID())
+				return
+			}
+			assert.Equal(t, remote.TraceID(), span.SpanContext().TraceID())
+			assert.Equal(t, remote.SpanID(), span.Parent().SpanID())
+			assert.Empty(t, span.Links())
+		})
+	}
+}
+
+func TestWithHistogramBuckets(t *testing.T) {
+	newRouter := func(t *testing.T, opts ...Option) (*fox.Router, *sdkmetric.ManualReader) {
+		t.Helper()
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", append(opts, WithMeterProvider(meter))...)))
+		require.NoError(t, err)
+		_, err = f.Add(fox.MethodPost, "/upload", func(c *fox.Context) {
+			_ = c.String(http.StatusOK, "ok")
+		})
+		require.NoError(t, err)
+		return f, reader
+	}
+
+	t.Run("explicit boundaries", func(t *testing.T) {
+		f, reader := newRouter(t,
+			WithRequestDurationBuckets(1, 30, 60),
+			WithRequestBodySizeBuckets(1<<20, 10<<20, 100<<20),
+			WithResponseBodySizeBuckets(1, 2),
+			WithSemConvStabilityOptIn(SemConvDup),
+		)
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("foo")))
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+
+		duration := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, duration.DataPoints, 1)
+		assert.Equal(t, []float64{1, 30, 60}, duration.DataPoints[0].Bounds)
+
+		oldDuration := findMetric(t, rm, "http.server.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, oldDuration.DataPoints, 1)
+		assert.Equal(t, []float64{1000, 30000, 60000}, oldDuration.DataPoints[0].Bounds)
+
+		reqSize := findMetric(t, rm, "http.server.request.body.size").Data.(metricdata.Histogram[int64])
+		require.Len(t, reqSize.DataPoints, 1)
+		assert.Equal(t, []float64{1 << 20, 10 << 20, 100 << 20}, reqSize.DataPoints[0].Bounds)
+
+		resSize := findMetric(t, rm, "http.server.response.body.size").Data.(metricdata.Histogram[int64])
+		require.Len(t, resSize.DataPoints, 1)
+		assert.Equal(t, []float64{1, 2}, resSize.DataPoints[0].Bounds)
+	})
+
+	t.Run("default boundaries", func(t *testing.T) {
+		f, reader := newRouter(t)
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", nil))
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+
+		duration := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, duration.DataPoints, 1)
+		assert.Equal(t, []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}, duration.DataPoints[0].Bounds)
+	})
+
+	t.Run("exponential histogram view", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithView(sdkmetric.NewView(
+				sdkmetric.Instrument{Name: "http.server.request.duration", Scope: instrumentation.Scope{Name: ScopeName}},
+				sdkmetric.Stream{Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20}},
+			)),
+		)
+		f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter), WithRequestDurationBuckets(1, 2))))
+		require.NoError(t, err)
+		_, err = f.Add(fox.MethodGet, "/", func(c *fox.Context) {})
+		require.NoError(t, err)
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		duration := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.ExponentialHistogram[float64])
+		require.Len(t, duration.DataPoints, 1)
+		assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+	})
+}
+
+func TestMetricsExemplars(t *testing.T) {
+	cases := []struct {
+		name        string
+		sampler     sdktrace.Sampler
+		opts        []Option
+		wantTraceID bool
+	}{
+		{
+			name:        "sampled span",
+			sampler:     sdktrace.AlwaysSample(),
+			wantTraceID: true,
+		},
+		{
+			name:        "unsampled span",
+			sampler:     sdktrace.NeverSample(),
+			wantTraceID: true,
+		},
+		{
+			name:        "unsampled span with trace based exemplars",
+			sampler:     sdktrace.NeverSample(),
+			opts:        []Option{WithTraceBasedExemplars()},
+			wantTraceID: false,
+		},
+		{
+			name:        "sampled span with trace based exemplars",
+			sampler:     sdktrace.AlwaysSample(),
+			opts:        []Option{WithTraceBasedExemplars()},
+			wantTraceID: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(tc.sampler))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithExemplarFilter(exemplar.AlwaysOnFilter))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter))...)),
+			)
+			require.NoError(t, err)
+
+			var spanCtx trace.SpanContext
+			_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+				spanCtx = trace.SpanContextFromContext(c.Request().Context())
+				// The handler may replace the request, the metrics are still recorded within the span context.
+				c.SetRequest(c.Request().WithContext(context.Background()))
+			})
+			require.NoError(t, err)
+
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+			require.True(t, spanCtx.IsValid())
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			require.Len(t, hist.DataPoints[0].Exemplars, 1)
+
+			ex := hist.DataPoints[0].Exemplars[0]
+			if tc.wantTraceID {
+				traceID := spanCtx.TraceID()
+				spanID := spanCtx.SpanID()
+				assert.Equal(t, traceID[:], ex.TraceID)
+				assert.Equal(t, spanID[:], ex.SpanID)
+				return
+			}
+			assert.Empty(t, ex.TraceID)
+			assert.Empty(t, ex.SpanID)
+		})
+	}
+
+	t.Run("default trace based filter", func(t *testing.T) {
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter))))
+		require.NoError(t, err)
+		_, err = f.Add(fox.MethodGet, "/", func(c *fox.Context) {})
+		require.NoError(t, err)
+
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, hist.DataPoints, 1)
+		assert.Empty(t, hist.DataPoints[0].Exemplars)
+	})
+}
+
+func TestWithTraceAndMetricFilters(t *testing.T) {
+	notHealthz := func(c *fox.Context) bool { return c.Pattern() != "/healthz" }
+	cases := []struct {
+		name        string
+		opts        []Option
+		wantSpan    bool
+		wantMetrics bool
+	}{
+		{
+			name:        "filter",
+			opts:        []Option{WithFilter(notHealthz)},
+			wantSpan:    false,
+			wantMetrics: false,
+		},
+		{
+			name:        "trace filter",
+			opts:        []Option{WithTraceFilter(notHealthz)},
+			wantSpan:    false,
+			wantMetrics: true,
+		},
+		{
+			name:        "metric filter",
+			opts:        []Option{WithMetricFilter(notHealthz)},
+			wantSpan:    true,
+			wantMetrics: false,
+		},
+		{
+			name:        "trace and metric filter",
+			opts:        []Option{WithTraceFilter(notHealthz), WithMetricFilter(notHealthz)},
+			wantSpan:    false,
+			wantMetrics: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter))...)),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/healthz", func(c *fox.Context) {})
+			require.NoError(t, err)
+
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if tc.wantSpan {
+				assert.Len(t, sr.Ended(), 1)
+			} else {
+				assert.Empty(t, sr.Ended())
+			}
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			if tc.wantMetrics {
+				hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+				assert.Len(t, hist.DataPoints, 1)
+			} else {
+				assert.Empty(t, rm.ScopeMetrics)
+			}
+		})
+	}
+}
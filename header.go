@@ -0,0 +1,55 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	requestHeaderPrefix  = "http.request.header."
+	responseHeaderPrefix = "http.response.header."
+)
+
+// capturedHeader is a header to record as a span attribute, with its canonical name and the normalized attribute key.
+type capturedHeader struct {
+	name string
+	key  attribute.Key
+}
+
+// newCapturedHeaders normalizes the header names for lookup, and derive the attribute keys using the lowercase header
+// name, as defined by the semantic conventions (e.g. "X-Request-Id" is recorded as "http.request.header.x-request-id").
+func newCapturedHeaders(prefix string, headers []string) []capturedHeader {
+	captured := make([]capturedHeader, 0, len(headers))
+	for _, h := range headers {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		captured = append(captured, capturedHeader{
+			name: textproto.CanonicalMIMEHeaderKey(h),
+			key:  attribute.Key(prefix + strings.ToLower(h)),
+		})
+	}
+	return captured
+}
+
+// headerAttributes returns the attributes for the captured headers present in h, redacted with r. Header values are
+// always recorded as a string slice, since a header may have multiple values.
+func headerAttributes(captured []capturedHeader, h http.Header, r Redactor) []attribute.KeyValue {
+	if len(captured) == 0 {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	for _, ch := range captured {
+		values := h[ch.name]
+		if len(values) == 0 {
+			continue
+		}
+		attrs = append(attrs, ch.key.StringSlice(r.RedactHeader(ch.name, values)))
+	}
+	return attrs
+}
@@ -0,0 +1,54 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithCapturedHeaders(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithTracerProvider(provider),
+			WithCapturedRequestHeaders("X-Request-Id", "accept", "X-Missing", ""),
+			WithCapturedResponseHeaders("Content-Type", "X-Cache"),
+		)),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/hello", func(c *fox.Context) {
+		c.Writer().Header().Add("X-Cache", "miss")
+		c.Writer().Header().Add("X-Cache", "stale")
+		_ = c.String(http.StatusOK, "hello")
+	})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	r.Header.Add("Accept", "text/plain")
+	r.Header.Add("Accept", "application/json")
+	r.Header.Set("Authorization", "Bearer secret")
+	f.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	attrs := spans[0].Attributes()
+	assert.Contains(t, attrs, attribute.StringSlice("http.request.header.x-request-id", []string{"abc"}))
+	assert.Contains(t, attrs, attribute.StringSlice("http.request.header.accept", []string{"text/plain", "application/json"}))
+	assert.Contains(t, attrs, attribute.StringSlice("http.response.header.content-type", []string{fox.MIMETextPlainCharsetUTF8}))
+	assert.Contains(t, attrs, attribute.StringSlice("http.response.header.x-cache", []string{"miss", "stale"}))
+	for _, attr := range attrs {
+		assert.NotEqual(t, attribute.Key("http.request.header.x-missing"), attr.Key)
+		assert.NotEqual(t, attribute.Key("http.request.header.authorization"), attr.Key)
+	}
+}
@@ -0,0 +1,73 @@
+package semconv
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// ConnectionSetupDurationName is the name of the metric recording the time
+// spent establishing new client connections. It is not part of the semantic
+// conventions, which define "http.client.connection.duration" as the lifetime
+// of a connection instead.
+const ConnectionSetupDurationName = "oteltracing.http.client.connection.setup.duration"
+
+// Client extends the HTTPClient with the connection setup duration metric.
+type Client struct {
+	HTTPClient
+
+	connectionSetupDuration metric.Float64Histogram
+}
+
+// NewClient returns a Client that records its metrics with the provided
+// meter.
+func NewClient(meter metric.Meter) Client {
+	client := Client{HTTPClient: NewHTTPClient(meter)}
+
+	var err error
+	client.connectionSetupDuration, err = meter.Float64Histogram(
+		ConnectionSetupDurationName,
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the DNS lookup, dial and TLS handshake of new outbound HTTP connections."),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	)
+	handleErr(err)
+
+	return client
+}
+
+// RecordConnectionSetupDuration records the time spent establishing a new
+// connection to send req, from the DNS lookup or dial start until the
+// connection is ready. The peer is the remote address of the connection, and
+// the elapsed time is in milliseconds.
+func (n Client) RecordConnectionSetupDuration(ctx context.Context, elapsedTime float64, req *http.Request, peer string) {
+	var h string
+	if req.URL != nil {
+		h = req.URL.Host
+	}
+	requestHost, requestPort := SplitHostPort(h)
+	if requestPort < 0 {
+		requestPort = 80
+		if req.URL != nil && req.URL.Scheme == "https" {
+			requestPort = 443
+		}
+	}
+
+	attributes := make([]attribute.KeyValue, 0, 5)
+	attributes = append(attributes,
+		semconv.ServerAddress(requestHost),
+		semconv.ServerPort(requestPort),
+		n.scheme(req),
+	)
+	if peerAddr, _ := SplitHostPort(peer); peerAddr != "" {
+		attributes = append(attributes, semconv.NetworkPeerAddress(peerAddr))
+	}
+	if _, protoVersion := netProtocol(req.Proto); protoVersion != "" {
+		attributes = append(attributes, semconv.NetworkProtocolVersion(protoVersion))
+	}
+
+	n.connectionSetupDuration.Record(ctx, elapsedTime/1000, metric.WithAttributeSet(attribute.NewSet(attributes...)))
+}
@@ -0,0 +1,49 @@
+package semconv
+
+import (
+	"os"
+	"strings"
+)
+
+// OTelSemConvStabilityOptIn is the environment variable used to opt in to the
+// stable HTTP semantic conventions, or to emit both the old and the stable
+// ones.
+const OTelSemConvStabilityOptIn = "OTEL_SEMCONV_STABILITY_OPT_IN"
+
+// Mode determines which HTTP semantic conventions are emitted.
+type Mode uint8
+
+const (
+	// ModeNew emits the stable HTTP semantic conventions only.
+	ModeNew Mode = iota
+	// ModeOld emits the HTTP semantic conventions v1.20.0 only.
+	ModeOld
+	// ModeDup emits both the stable and the v1.20.0 HTTP semantic conventions.
+	ModeDup
+)
+
+// ModeFromEnv returns the mode selected by the OTEL_SEMCONV_STABILITY_OPT_IN
+// environment variable. See ParseMode.
+func ModeFromEnv() Mode {
+	return ParseMode(os.Getenv(OTelSemConvStabilityOptIn))
+}
+
+// ParseMode parses the value of the OTEL_SEMCONV_STABILITY_OPT_IN environment
+// variable. The value is a comma-separated list, where "http/dup" selects
+// ModeDup and "http/old" selects ModeOld. "http/old" is not part of the
+// specification, which only allows opting in to the stable conventions, but
+// since they are emitted by default, it is the only way to select ModeOld
+// from the environment. If both are present, "http/dup" takes precedence. Any
+// other value selects ModeNew.
+func ParseMode(value string) Mode {
+	mode := ModeNew
+	for v := range strings.SplitSeq(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "http/dup":
+			return ModeDup
+		case "http/old":
+			mode = ModeOld
+		}
+	}
+	return mode
+}
@@ -0,0 +1,244 @@
+package semconv
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	semconvOld "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// OldHTTPServer records the HTTP server telemetry using the semantic
+// conventions v1.20.0.
+type OldHTTPServer struct {
+	requestSizeHistogram  metric.Int64Histogram
+	responseSizeHistogram metric.Int64Histogram
+	durationHistogram     metric.Float64Histogram
+}
+
+// NewOldHTTPServer returns an OldHTTPServer that records the
+// "http.server.duration", "http.server.request.size" and
+// "http.server.response.size" metrics with the provided meter. The mode of
+// the options is ignored.
+func NewOldHTTPServer(meter metric.Meter, opts ...ServerOption) OldHTTPServer {
+	if meter == nil {
+		meter = noop.Meter{}
+	}
+
+	cfg := newServerConfig(opts)
+	durationBuckets := make([]float64, len(cfg.durationBuckets))
+	for i, b := range cfg.durationBuckets {
+		durationBuckets[i] = b * 1000
+	}
+
+	server := OldHTTPServer{}
+
+	var err error
+	server.requestSizeHistogram, err = meter.Int64Histogram(
+		"http.server.request.size",
+		append(
+			int64HistogramOptions(cfg.requestSizeBuckets),
+			metric.WithUnit("By"),
+			metric.WithDescription("Measures the size of HTTP request messages."),
+		)...,
+	)
+	handleErr(err)
+
+	server.responseSizeHistogram, err = meter.Int64Histogram(
+		"http.server.response.size",
+		append(
+			int64HistogramOptions(cfg.responseSizeBuckets),
+			metric.WithUnit("By"),
+			metric.WithDescription("Measures the size of HTTP response messages."),
+		)...,
+	)
+	handleErr(err)
+
+	server.durationHistogram, err = meter.Float64Histogram(
+		"http.server.duration",
+		append(
+			float64HistogramOptions(durationBuckets),
+			metric.WithUnit("ms"),
+			metric.WithDescription("Measures the duration of inbound HTTP requests."),
+		)...,
+	)
+	handleErr(err)
+
+	return server
+}
+
+// RequestTraceAttrs returns the v1.20.0 trace attributes for an HTTP request
+// received by a server. The query is recorded in the "http.target" attribute
+// in place of the raw query of the request, so that it can be redacted. See
+// Server.RequestTraceAttrs.
+func (o OldHTTPServer) RequestTraceAttrs(server string, req *http.Request, opts RequestTraceAttrsOpts, query string) []attribute.KeyValue {
+	host, hostPort := o.host(server, req)
+
+	attrs := make([]attribute.KeyValue, 0, 11)
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	attrs = append(attrs,
+		semconvOld.HTTPMethod(method),
+		o.scheme(req.TLS != nil),
+		semconvOld.NetHostName(host),
+	)
+	if hostPort > 0 {
+		attrs = append(attrs, semconvOld.NetHostPort(hostPort))
+	}
+
+	peer, peerPort := SplitHostPort(req.RemoteAddr)
+	if peer != "" {
+		attrs = append(attrs, semconvOld.NetSockPeerAddr(peer))
+		if peerPort > 0 {
+			attrs = append(attrs, semconvOld.NetSockPeerPort(peerPort))
+		}
+	}
+
+	if useragent := req.UserAgent(); useragent != "" {
+		attrs = append(attrs, semconvOld.UserAgentOriginal(useragent))
+	}
+
+	clientIP := opts.HTTPClientIP
+	if clientIP == "" {
+		clientIP = serverClientIP(req.Header.Get("X-Forwarded-For"))
+	}
+	if clientIP != "" {
+		attrs = append(attrs, semconvOld.HTTPClientIP(clientIP))
+	}
+
+	if req.URL != nil && req.URL.Path != "" {
+		target := req.URL.EscapedPath()
+		if query != "" {
+			target += "?" + query
+		}
+		attrs = append(attrs, semconvOld.HTTPTarget(target))
+	}
+
+	protoName, protoVersion := netProtocol(req.Proto)
+	if protoName != "" && protoName != "http" {
+		attrs = append(attrs, semconvOld.NetProtocolName(protoName))
+	}
+	if protoVersion != "" {
+		attrs = append(attrs, semconvOld.NetProtocolVersion(protoVersion))
+	}
+
+	return attrs
+}
+
+// ResponseTraceAttrs returns the v1.20.0 trace attributes for telemetry from
+// an HTTP response. See HTTPServer.ResponseTraceAttrs.
+func (o OldHTTPServer) ResponseTraceAttrs(resp ResponseTelemetry) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 3)
+	if resp.ReadBytes > 0 {
+		attrs = append(attrs, semconvOld.HTTPRequestContentLength(int(resp.ReadBytes)))
+	}
+	if resp.WriteBytes > 0 {
+		attrs = append(attrs, semconvOld.HTTPResponseContentLength(int(resp.WriteBytes)))
+	}
+	if resp.StatusCode > 0 {
+		attrs = append(attrs, semconvOld.HTTPStatusCode(resp.StatusCode))
+	}
+	return attrs
+}
+
+// MetricAttributes returns the v1.20.0 metric attributes for an HTTP request
+// received by a server.
+func (o OldHTTPServer) MetricAttributes(server string, req *http.Request, statusCode int, route string, additionalAttributes []attribute.KeyValue) []attribute.KeyValue {
+	host, hostPort := o.host(server, req)
+
+	attributes := slices.Grow(additionalAttributes, 8)
+	attributes = append(attributes,
+		o.method(req.Method),
+		o.scheme(req.TLS != nil),
+		semconvOld.NetHostName(host),
+	)
+	if hostPort > 0 {
+		attributes = append(attributes, semconvOld.NetHostPort(hostPort))
+	}
+
+	protoName, protoVersion := netProtocol(req.Proto)
+	if protoName != "" {
+		attributes = append(attributes, semconvOld.NetProtocolName(protoName))
+	}
+	if protoVersion != "" {
+		attributes = append(attributes, semconvOld.NetProtocolVersion(protoVersion))
+	}
+
+	if statusCode > 0 {
+		attributes = append(attributes, semconvOld.HTTPStatusCode(statusCode))
+	}
+
+	if route != "" {
+		attributes = append(attributes, semconvOld.HTTPRoute(route))
+	}
+	return attributes
+}
+
+// ActiveRequestsAttributes returns the v1.20.0 attributes for the
+// "http.server.active_requests" metric. See HTTPServer.ActiveRequestsAttributes.
+func (o OldHTTPServer) ActiveRequestsAttributes(server string, req *http.Request, route string) []attribute.KeyValue {
+	host, hostPort := o.host(server, req)
+
+	attributes := make([]attribute.KeyValue, 0, 5)
+	attributes = append(attributes,
+		o.method(req.Method),
+		o.scheme(req.TLS != nil),
+		semconvOld.NetHostName(host),
+	)
+	if hostPort > 0 {
+		attributes = append(attributes, semconvOld.NetHostPort(hostPort))
+	}
+	if route != "" {
+		attributes = append(attributes, semconvOld.HTTPRoute(route))
+	}
+	return attributes
+}
+
+// RecordMetrics records the v1.20.0 server metrics. The request duration is
+// in milliseconds.
+func (o OldHTTPServer) RecordMetrics(ctx context.Context, md MetricData, responseSize int64, opt metric.MeasurementOption) {
+	recordOpts := metricRecordOptionPool.Get().(*[]metric.RecordOption)
+	*recordOpts = append(*recordOpts, opt)
+	o.requestSizeHistogram.Record(ctx, md.RequestSize, *recordOpts...)
+	o.responseSizeHistogram.Record(ctx, responseSize, *recordOpts...)
+	o.durationHistogram.Record(ctx, md.ElapsedTime, *recordOpts...)
+	*recordOpts = (*recordOpts)[:0]
+	metricRecordOptionPool.Put(recordOpts)
+}
+
+func (o OldHTTPServer) host(server string, req *http.Request) (string, int) {
+	var host string
+	var p int
+	if server == "" {
+		host, p = SplitHostPort(req.Host)
+	} else {
+		// Prioritize the primary server name.
+		host, p = SplitHostPort(server)
+		if p < 0 {
+			_, p = SplitHostPort(req.Host)
+		}
+	}
+	return host, requiredHTTPPort(req.TLS != nil, p)
+}
+
+// method returns the "http.method" metric attribute, with unknown methods
+// recorded as "_OTHER" to bound the cardinality.
+func (o OldHTTPServer) method(method string) attribute.KeyValue {
+	if method == "" {
+		return semconvOld.HTTPMethod(http.MethodGet)
+	}
+	return semconvOld.HTTPMethod(standardizeHTTPMethod(method))
+}
+
+func (o OldHTTPServer) scheme(https bool) attribute.KeyValue { //nolint:revive // ignore linter
+	if https {
+		return semconvOld.HTTPSchemeHTTPS
+	}
+	return semconvOld.HTTPSchemeHTTP
+}
@@ -0,0 +1,336 @@
+package semconv
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/semconv/v1.39.0/httpconv"
+)
+
+// Server extends the HTTPServer with the "http.server.active_requests"
+// metric, configurable histogram buckets, reusable measurement options, and
+// the HTTP semantic conventions v1.20.0. Depending on its Mode, it emits the
+// stable conventions, the v1.20.0 ones, or both.
+type Server struct {
+	HTTPServer
+
+	activeRequestsCounter httpconv.ServerActiveRequests
+
+	mode Mode
+	old  OldHTTPServer
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*serverConfig)
+
+type serverConfig struct {
+	mode                Mode
+	durationBuckets     []float64
+	requestSizeBuckets  []float64
+	responseSizeBuckets []float64
+}
+
+func newServerConfig(opts []ServerOption) serverConfig {
+	cfg := serverConfig{
+		durationBuckets: []float64{
+			0.005, 0.01, 0.025, 0.05, 0.075, 0.1,
+			0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10,
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithMode selects the HTTP semantic conventions emitted by the Server. The
+// default is ModeNew.
+func WithMode(mode Mode) ServerOption {
+	return func(c *serverConfig) {
+		c.mode = mode
+	}
+}
+
+// WithDurationBuckets sets the explicit bucket boundaries, in seconds, of the
+// request duration histogram. The boundaries are converted to milliseconds for
+// the v1.20.0 "http.server.duration" histogram.
+func WithDurationBuckets(bounds []float64) ServerOption {
+	return func(c *serverConfig) {
+		c.durationBuckets = bounds
+	}
+}
+
+// WithRequestSizeBuckets sets the explicit bucket boundaries, in bytes, of the
+// request body size histogram. If not set, the SDK default boundaries are
+// used.
+func WithRequestSizeBuckets(bounds []float64) ServerOption {
+	return func(c *serverConfig) {
+		c.requestSizeBuckets = bounds
+	}
+}
+
+// WithResponseSizeBuckets sets the explicit bucket boundaries, in bytes, of
+// the response body size histogram. If not set, the SDK default boundaries are
+// used.
+func WithResponseSizeBuckets(bounds []float64) ServerOption {
+	return func(c *serverConfig) {
+		c.responseSizeBuckets = bounds
+	}
+}
+
+// NewServer returns a Server that records its metrics with the provided
+// meter.
+func NewServer(meter metric.Meter, opts ...ServerOption) Server {
+	cfg := newServerConfig(opts)
+	server := Server{mode: cfg.mode}
+	if server.mode != ModeNew {
+		server.old = NewOldHTTPServer(meter, opts...)
+	}
+
+	var err error
+	server.requestBodySizeHistogram, err = httpconv.NewServerRequestBodySize(meter, int64HistogramOptions(cfg.requestSizeBuckets)...)
+	handleErr(err)
+
+	server.responseBodySizeHistogram, err = httpconv.NewServerResponseBodySize(meter, int64HistogramOptions(cfg.responseSizeBuckets)...)
+	handleErr(err)
+
+	server.requestDurationHistogram, err = httpconv.NewServerRequestDuration(meter, float64HistogramOptions(cfg.durationBuckets)...)
+	handleErr(err)
+
+	server.activeRequestsCounter, err = httpconv.NewServerActiveRequests(meter)
+	handleErr(err)
+	return server
+}
+
+// RequestTraceAttrs returns trace attributes for an HTTP request received by
+// a server. See HTTPServer.RequestTraceAttrs.
+//
+// The query is recorded in place of the raw query of the request, so that
+// sensitive values can be redacted by the caller. It is recorded as the
+// "url.query" attribute of the stable conventions, and as part of the
+// "http.target" attribute of the v1.20.0 ones.
+func (n Server) RequestTraceAttrs(server string, req *http.Request, opts RequestTraceAttrsOpts, query string) []attribute.KeyValue {
+	switch n.mode {
+	case ModeOld:
+		return n.old.RequestTraceAttrs(server, req, opts, query)
+	case ModeDup:
+		return append(n.requestTraceAttrs(server, req, opts, query), n.old.RequestTraceAttrs(server, req, opts, query)...)
+	default:
+		return n.requestTraceAttrs(server, req, opts, query)
+	}
+}
+
+func (n Server) requestTraceAttrs(server string, req *http.Request, opts RequestTraceAttrsOpts, query string) []attribute.KeyValue {
+	attrs := n.HTTPServer.RequestTraceAttrs(server, req, opts)
+	if query != "" {
+		attrs = append(attrs, semconv.URLQuery(query))
+	}
+	return attrs
+}
+
+// ResponseTraceAttrs returns trace attributes for telemetry from an HTTP
+// response. See HTTPServer.ResponseTraceAttrs.
+//
+// The attributes of the semantic conventions v1.20.0 are returned instead of,
+// or in addition to, the stable ones depending on the Mode of the server.
+func (n Server) ResponseTraceAttrs(resp ResponseTelemetry) []attribute.KeyValue {
+	switch n.mode {
+	case ModeOld:
+		return n.old.ResponseTraceAttrs(resp)
+	case ModeDup:
+		return append(n.HTTPServer.ResponseTraceAttrs(resp), n.old.ResponseTraceAttrs(resp)...)
+	default:
+		return n.HTTPServer.ResponseTraceAttrs(resp)
+	}
+}
+
+// ServerKey holds the normalized request values from which the server
+// attributes of the metrics are derived. Requests with the same ServerKey
+// share the same server attributes, so it can be used to cache attribute sets
+// without being affected by the cardinality of the raw request values.
+type ServerKey struct {
+	// Method is the request method, with unknown methods folded into
+	// "_OTHER".
+	Method string
+	// Host is the request host, or empty if the primary server name is known.
+	Host string
+	// Port is the server port, or -1 if it is the default port of the scheme.
+	Port int
+	TLS  bool
+}
+
+// ServerKey returns the ServerKey of req. See MetricOption.
+func (n Server) ServerKey(server string, req *http.Request) ServerKey {
+	var host string
+	var p int
+	if server == "" {
+		host, p = SplitHostPort(req.Host)
+	} else {
+		// The host is derived from the primary server name.
+		_, p = SplitHostPort(server)
+		if p < 0 {
+			_, p = SplitHostPort(req.Host)
+		}
+	}
+	method := req.Method
+	if method != "" {
+		method = standardizeHTTPMethod(method)
+	}
+	return ServerKey{
+		Method: method,
+		Host:   host,
+		Port:   requiredHTTPPort(req.TLS != nil, p),
+		TLS:    req.TLS != nil,
+	}
+}
+
+// ServerMetricOption holds the measurement options of the server metrics, for
+// each of the semantic conventions emitted by the server.
+type ServerMetricOption struct {
+	new metric.MeasurementOption
+	old metric.MeasurementOption
+	// set is the attribute set of the stable conventions, or of the v1.20.0
+	// ones in ModeOld.
+	set attribute.Set
+}
+
+// Equivalent returns a comparable value identifying the attribute set of the
+// server metrics.
+func (o ServerMetricOption) Equivalent() attribute.Distinct {
+	return o.set.Equivalent()
+}
+
+// MetricOption returns the measurement options holding the attribute sets of
+// the server metrics. The options may be computed once and reused with
+// RecordMetricsWithOption for requests sharing the same attributes.
+func (n Server) MetricOption(server string, attrs MetricAttributes) ServerMetricOption {
+	var o ServerMetricOption
+	if n.mode != ModeOld {
+		// MetricAttributes may append to the additional attributes, which are
+		// shared with the old conventions.
+		additionalAttributes := slices.Clip(attrs.AdditionalAttributes)
+		attributes := n.MetricAttributes(server, attrs.Req, attrs.StatusCode, attrs.Route, additionalAttributes)
+		o.set = attribute.NewSet(attributes...)
+		o.new = metric.WithAttributeSet(o.set)
+	}
+	if n.mode != ModeNew {
+		attributes := n.old.MetricAttributes(server, attrs.Req, attrs.StatusCode, attrs.Route, slices.Clip(attrs.AdditionalAttributes))
+		set := attribute.NewSet(attributes...)
+		if n.mode == ModeOld {
+			o.set = set
+		}
+		o.old = metric.WithAttributeSet(set)
+	}
+	return o
+}
+
+// FixedMetricOption returns the measurement options recording the server
+// metrics with the provided attributes only, for each of the conventions
+// emitted by the server. Unlike MetricOption, the request attributes are not
+// added.
+func (n Server) FixedMetricOption(attrs ...attribute.KeyValue) ServerMetricOption {
+	o := ServerMetricOption{set: attribute.NewSet(attrs...)}
+	if n.mode != ModeOld {
+		o.new = metric.WithAttributeSet(o.set)
+	}
+	if n.mode != ModeNew {
+		o.old = metric.WithAttributeSet(o.set)
+	}
+	return o
+}
+
+// RecordMetricsWithOption records the server metrics with the measurement
+// options returned by MetricOption.
+func (n Server) RecordMetricsWithOption(ctx context.Context, md MetricData, responseSize int64, o ServerMetricOption) {
+	if o.new != nil {
+		recordOpts := metricRecordOptionPool.Get().(*[]metric.RecordOption)
+		*recordOpts = append(*recordOpts, o.new)
+		n.requestBodySizeHistogram.Inst().Record(ctx, md.RequestSize, *recordOpts...)
+		n.responseBodySizeHistogram.Inst().Record(ctx, responseSize, *recordOpts...)
+		n.requestDurationHistogram.Inst().Record(ctx, md.ElapsedTime/1000.0, *recordOpts...)
+		*recordOpts = (*recordOpts)[:0]
+		metricRecordOptionPool.Put(recordOpts)
+	}
+	if o.old != nil {
+		n.old.RecordMetrics(ctx, md, responseSize, o.old)
+	}
+}
+
+// ActiveRequestsAttributes returns the attributes for the
+// "http.server.active_requests" metric. If route is not empty, the
+// "http.route" attribute is included.
+func (n Server) ActiveRequestsAttributes(server string, req *http.Request, route string) []attribute.KeyValue {
+	num := 3
+	var host string
+	var p int
+	if server == "" {
+		host, p = SplitHostPort(req.Host)
+	} else {
+		// Prioritize the primary server name.
+		host, p = SplitHostPort(server)
+		if p < 0 {
+			_, p = SplitHostPort(req.Host)
+		}
+	}
+	hostPort := requiredHTTPPort(req.TLS != nil, p)
+	if hostPort > 0 {
+		num++
+	}
+	if route != "" {
+		num++
+	}
+
+	attributes := make([]attribute.KeyValue, 0, num)
+	attributes = append(attributes,
+		semconv.HTTPRequestMethodKey.String(standardizeHTTPMethod(req.Method)),
+		n.scheme(req.TLS != nil),
+		semconv.ServerAddress(host))
+
+	if hostPort > 0 {
+		attributes = append(attributes, semconv.ServerPort(hostPort))
+	}
+	if route != "" {
+		attributes = append(attributes, semconv.HTTPRoute(route))
+	}
+	return attributes
+}
+
+// ActiveRequestsOption returns the measurement option holding the attribute
+// set of the "http.server.active_requests" metric. See
+// ActiveRequestsAttributes. Since both conventions share the same instrument,
+// the stable attributes are used in ModeDup to avoid counting the requests
+// twice.
+func (n Server) ActiveRequestsOption(server string, req *http.Request, route string) metric.MeasurementOption {
+	if n.mode == ModeOld {
+		return metric.WithAttributeSet(attribute.NewSet(n.old.ActiveRequestsAttributes(server, req, route)...))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(n.ActiveRequestsAttributes(server, req, route)...))
+}
+
+// AddActiveRequests adds incr to the "http.server.active_requests" metric
+// with a measurement option returned by ActiveRequestsOption.
+func (n Server) AddActiveRequests(ctx context.Context, incr int64, o metric.MeasurementOption) {
+	addOpts := metricAddOptionPool.Get().(*[]metric.AddOption)
+	*addOpts = append(*addOpts, o)
+	n.activeRequestsCounter.Inst().Add(ctx, incr, *addOpts...)
+	*addOpts = (*addOpts)[:0]
+	metricAddOptionPool.Put(addOpts)
+}
+
+func int64HistogramOptions(bounds []float64) []metric.Int64HistogramOption {
+	if len(bounds) == 0 {
+		return nil
+	}
+	return []metric.Int64HistogramOption{metric.WithExplicitBucketBoundaries(bounds...)}
+}
+
+func float64HistogramOptions(bounds []float64) []metric.Float64HistogramOption {
+	if len(bounds) == 0 {
+		return nil
+	}
+	return []metric.Float64HistogramOption{metric.WithExplicitBucketBoundaries(bounds...)}
+}
@@ -14,7 +14,20 @@ import (
 )
 
 var defaultSpanNameFormatter SpanNameFormatter = func(c *fox.Context) string {
-	method := strings.ToUpper(c.Request().Method)
+	method := spanMethod(c.Request().Method)
+	if name := scopeSpanName(method, c.Scope()); name != "" {
+		return name
+	}
+	if path := c.Pattern(); path != "" {
+		return method + " " + path
+	}
+
+	return method
+}
+
+// spanMethod returns the method to use in a span name, or "HTTP" for unknown methods.
+func spanMethod(method string) string {
+	method = strings.ToUpper(method)
 	if !slices.Contains([]string{
 		http.MethodGet, http.MethodHead,
 		http.MethodPost, http.MethodPut,
@@ -22,13 +35,8 @@ var defaultSpanNameFormatter SpanNameFormatter = func(c *fox.Context) string {
 		http.MethodConnect, http.MethodOptions,
 		http.MethodTrace,
 	}, method) {
-		method = "HTTP"
-	}
-
-	if path := c.Pattern(); path != "" {
-		return method + " " + path
+		return "HTTP"
 	}
-
 	return method
 }
 
@@ -56,15 +64,41 @@ type SpanNameFormatter func(c *fox.Context) string
 type MetricAttributesFunc func(c *fox.Context) []attribute.KeyValue
 
 type config struct {
-	provider   trace.TracerProvider
-	propagator propagation.TextMapPropagator
-	meter      metric.MeterProvider
-	resolver   fox.ClientIPResolver
-	carrier    func(r *http.Request) propagation.TextMapCarrier
-	spanFmt    SpanNameFormatter
-	attrsFn    MetricAttributesFunc
-	filters    []Filter
-	spanOpts   []trace.SpanStartOption
+	provider       trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	meter          metric.MeterProvider
+	resolver       fox.ClientIPResolver
+	carrier        func(r *http.Request) propagation.TextMapCarrier
+	spanFmt        SpanNameFormatter
+	statusFn       SpanStatusClassifier
+	attrsFn        MetricAttributesFunc
+	filters        []Filter
+	traceFilters   []Filter
+	metricFilters  []Filter
+	spanOpts       []trace.SpanStartOption
+	repanic        bool
+	activeRoute    bool
+	traceEvents    bool
+	connMetric     bool
+	respProps      []ResponsePropagator
+	publicFn       func(c *fox.Context) bool
+	reqHeaders     []capturedHeader
+	resHeaders     []capturedHeader
+	redactor       Redactor
+	params         *routeParams
+	skipTrace      fox.HandlerScope
+	skipMetrics    fox.HandlerScope
+	semconv        SemConvMode
+	buckets        histogramBuckets
+	traceExemplars bool
+	cardinality    int
+	baggage        *baggageAttrs
+}
+
+type histogramBuckets struct {
+	duration     []float64
+	requestSize  []float64
+	responseSize []float64
 }
 
 func defaultConfig() *config {
@@ -75,8 +109,11 @@ func defaultConfig() *config {
 		carrier: func(r *http.Request) propagation.TextMapCarrier {
 			return propagation.HeaderCarrier(r.Header)
 		},
-		attrsFn: func(c *fox.Context) []attribute.KeyValue { return nil },
-		spanFmt: defaultSpanNameFormatter,
+		attrsFn:  func(c *fox.Context) []attribute.KeyValue { return nil },
+		spanFmt:  defaultSpanNameFormatter,
+		statusFn: DefaultSpanStatusClassifier,
+		redactor: DefaultRedactor,
+		repanic:  true,
 	}
 }
 
@@ -122,7 +159,9 @@ func WithTextMapCarrier(fn func(r *http.Request) propagation.TextMapCarrier) Opt
 }
 
 // WithSpanNameFormatter takes a function that will be called on every request
-// and the returned string will become the Span Name.
+// and the returned string will become the Span Name. If the function returns an
+// empty string, the default name of the handler scope (e.g. "GET not found") is
+// used, or the request method for a matched route.
 func WithSpanNameFormatter(fn SpanNameFormatter) Option {
 	return optionFunc(func(c *config) {
 		if fn != nil {
@@ -131,16 +170,66 @@ func WithSpanNameFormatter(fn SpanNameFormatter) Option {
 	})
 }
 
+// WithSpanStatusClassifier takes a function that will be called on every request to determine the span status
+// from the response status code. If none is specified, [DefaultSpanStatusClassifier] is used. Note that a panic or an
+// error recorded with [WithErrorStatus] always set the span status to error.
+func WithSpanStatusClassifier(fn SpanStatusClassifier) Option {
+	return optionFunc(func(c *config) {
+		if fn != nil {
+			c.statusFn = fn
+		}
+	})
+}
+
 // WithFilter adds a filter to the list of filters used by the handler. If any filter indicates to exclude a request
 // then the request will not be traced. All filters must allow a request to be traced for a Span to be created.
 // If no filters are provided then all requests are traced. Filters will be invoked for each processed request,
-// it is advised to make them simple and fast.
+// it is advised to make them simple and fast. A request excluded by this filter is neither traced nor metered,
+// see [WithTraceFilter] and [WithMetricFilter] to exclude it from tracing or metrics only.
 func WithFilter(f ...Filter) Option {
 	return optionFunc(func(c *config) {
 		c.filters = append(c.filters, f...)
 	})
 }
 
+// WithTraceFilter adds a filter to the list of filters used to decide whether a request is traced. If any filter
+// indicates to exclude a request, no span is created, but metrics are still recorded, unless excluded by
+// [WithFilter] or [WithMetricFilter]. Filters will be invoked for each processed request, it is advised to make
+// them simple and fast.
+func WithTraceFilter(f ...Filter) Option {
+	return optionFunc(func(c *config) {
+		c.traceFilters = append(c.traceFilters, f...)
+	})
+}
+
+// WithMetricFilter adds a filter to the list of filters used to decide whether a request is metered. If any filter
+// indicates to exclude a request, no metric is recorded, but the request is still traced, unless excluded by
+// [WithFilter] or [WithTraceFilter]. Filters will be invoked for each processed request, it is advised to make
+// them simple and fast.
+func WithMetricFilter(f ...Filter) Option {
+	return optionFunc(func(c *config) {
+		c.metricFilters = append(c.metricFilters, f...)
+	})
+}
+
+// WithSkipTracing disables tracing for requests handled in the provided scopes, which may be combined with the
+// bitwise OR operator (e.g. fox.RedirectSlashHandler|fox.RedirectPathHandler). Metrics are still recorded, unless
+// disabled with [WithSkipMetrics].
+func WithSkipTracing(scopes fox.HandlerScope) Option {
+	return optionFunc(func(c *config) {
+		c.skipTrace |= scopes
+	})
+}
+
+// WithSkipMetrics disables metrics for requests handled in the provided scopes, which may be combined with the
+// bitwise OR operator (e.g. fox.NoRouteHandler|fox.NoMethodHandler). Requests are still traced, unless disabled
+// with [WithSkipTracing].
+func WithSkipMetrics(scopes fox.HandlerScope) Option {
+	return optionFunc(func(c *config) {
+		c.skipMetrics |= scopes
+	})
+}
+
 // WithSpanStartOptions configures an additional set of trace.SpanStartOptions, which are applied to each new span.
 func WithSpanStartOptions(opts ...trace.SpanStartOption) Option {
 	return optionFunc(func(c *config) {
@@ -178,3 +267,265 @@ func WithClientIPResolver(resolver fox.ClientIPResolver) Option {
 		}
 	})
 }
+
+// WithRepanic specifies whether the middleware should re-panic after recording a panic raised by a handler.
+// The panic is always recorded as an exception on the span, the span status is set to error and the request
+// is accounted as a 500 in metrics. When enabled (the default), the panic is propagated to the upstream middleware
+// (e.g. [fox.Recovery]). When disabled, the panic is swallowed and a 500 status code response is written if the
+// handler has not written a response yet. Note that a panic with [http.ErrAbortHandler] is always propagated.
+func WithRepanic(enable bool) Option {
+	return optionFunc(func(c *config) {
+		c.repanic = enable
+	})
+}
+
+// WithActiveRequestsRoute includes the "http.route" attribute in the "http.server.active_requests" metric. By default,
+// only the attributes required by the semantic conventions are recorded (method, scheme, server address and port).
+func WithActiveRequestsRoute() Option {
+	return optionFunc(func(c *config) {
+		c.activeRoute = true
+	})
+}
+
+// WithClientTraceEvents records the connection phases of outgoing requests made with [Transport] as span events, using
+// [net/http/httptrace]. This includes DNS resolution, connection establishment, TLS handshake, connection reuse, request
+// write and first response byte. This option is ignored by [Middleware].
+func WithClientTraceEvents() Option {
+	return optionFunc(func(c *config) {
+		c.traceEvents = true
+	})
+}
+
+// WithClientConnectionDuration records the "oteltracing.http.client.connection.setup.duration" metric for outgoing
+// requests made with [Transport], using [net/http/httptrace]. The duration, in seconds, is the connection setup latency,
+// measured from the DNS lookup or dial start until the connection is established, and is only recorded for new
+// connections. Note that this differs from the semantic conventions "http.client.connection.duration" metric, which
+// measures the lifetime of a connection and is not recorded by this package. This option is ignored by [Middleware].
+func WithClientConnectionDuration() Option {
+	return optionFunc(func(c *config) {
+		c.connMetric = true
+	})
+}
+
+// WithResponsePropagation injects the span context of the server span into the response headers using the provided
+// propagators, before the handler writes the response. See [TraceResponsePropagator] and [ServerTimingPropagator] for
+// the built-in formats. Response propagation is disabled by default, since it exposes the trace id to the client.
+func WithResponsePropagation(propagators ...ResponsePropagator) Option {
+	return optionFunc(func(c *config) {
+		for _, p := range propagators {
+			if p != nil {
+				c.respProps = append(c.respProps, p)
+			}
+		}
+	})
+}
+
+// WithPublicEndpoint configures the middleware to handle all requests as coming from a public endpoint. Instead of using
+// the span context extracted from the request as the parent, a new root span is started and the extracted remote span
+// context, if valid, is added as a link. This prevents untrusted callers from joining or forcing the sampling of traces.
+func WithPublicEndpoint() Option {
+	return optionFunc(func(c *config) {
+		c.publicFn = func(c *fox.Context) bool { return true }
+	})
+}
+
+// WithPublicEndpointFn is like [WithPublicEndpoint], but only handles a request as coming from a public endpoint if fn
+// returns true. The function is invoked for each processed request, it is advised to make it simple and fast.
+func WithPublicEndpointFn(fn func(c *fox.Context) bool) Option {
+	return optionFunc(func(c *config) {
+		c.publicFn = fn
+	})
+}
+
+// WithCapturedRequestHeaders records the provided request headers as "http.request.header.<key>" span attributes,
+// where <key> is the lowercase header name. Multi-valued headers are recorded as a string slice. Header capture is
+// opt-in, since headers may carry sensitive data or have a high cardinality.
+func WithCapturedRequestHeaders(headers ...string) Option {
+	return optionFunc(func(c *config) {
+		c.reqHeaders = append(c.reqHeaders, newCapturedHeaders(requestHeaderPrefix, headers)...)
+	})
+}
+
+// WithCapturedResponseHeaders records the provided response headers as "http.response.header.<key>" span attributes,
+// where <key> is the lowercase header name. Multi-valued headers are recorded as a string slice. Header capture is
+// opt-in, since headers may carry sensitive data or have a high cardinality.
+func WithCapturedResponseHeaders(headers ...string) Option {
+	return optionFunc(func(c *config) {
+		c.resHeaders = append(c.resHeaders, newCapturedHeaders(responseHeaderPrefix, headers)...)
+	})
+}
+
+// WithRedactor specifies the [Redactor] used to replace sensitive values in the captured headers and in the
+// "url.query" and "url.full" attributes. If none is specified, [DefaultRedactor] is used.
+func WithRedactor(r Redactor) Option {
+	return optionFunc(func(c *config) {
+		if r != nil {
+			c.redactor = r
+		}
+	})
+}
+
+// WithRouteParams records the matched route parameters as "http.route.param.<name>" span attributes (e.g.
+// "http.route.param.name" for the route "/hello/{name}"). If names are provided, only the listed parameters are
+// recorded, otherwise all parameters are recorded. Route parameters are never added to metric attributes, since
+// they have an unbounded cardinality. See also [WithRouteParamsDenylist] and [WithRouteParamTransformer].
+func WithRouteParams(names ...string) Option {
+	return optionFunc(func(c *config) {
+		if c.params == nil {
+			c.params = new(routeParams)
+		}
+		c.params.enabled = true
+		if len(names) > 0 && c.params.allow == nil {
+			c.params.allow = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			c.params.allow[name] = struct{}{}
+		}
+	})
+}
+
+// WithRouteParamsDenylist records the matched route parameters as span attributes like [WithRouteParams], except
+// for the listed parameters. The denylist takes precedence over the allowlist configured with [WithRouteParams].
+func WithRouteParamsDenylist(names ...string) Option {
+	return optionFunc(func(c *config) {
+		if c.params == nil {
+			c.params = new(routeParams)
+		}
+		c.params.enabled = true
+		if c.params.deny == nil {
+			c.params.deny = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			c.params.deny[name] = struct{}{}
+		}
+	})
+}
+
+// WithRouteParamTransformer takes a function that will be called for each recorded route parameter, and the
+// returned string will become the attribute value. This can be used to truncate, mask or hash values, see
+// [HashRouteParam]. This option has no effect unless route parameters are recorded with [WithRouteParams] or
+// [WithRouteParamsDenylist].
+func WithRouteParamTransformer(fn RouteParamTransformer) Option {
+	return optionFunc(func(c *config) {
+		if fn == nil {
+			return
+		}
+		if c.params == nil {
+			c.params = new(routeParams)
+		}
+		c.params.transform = fn
+	})
+}
+
+// WithSemConvStabilityOptIn selects the HTTP semantic conventions emitted by the [Middleware], for both the span
+// attributes and the metric instruments. If not set, the mode is read from the OTEL_SEMCONV_STABILITY_OPT_IN
+// environment variable, see [SemConvStabilityOptInEnv]. This option has no effect on the [Transport], which always
+// emits the stable conventions.
+func WithSemConvStabilityOptIn(mode SemConvMode) Option {
+	return optionFunc(func(c *config) {
+		c.semconv = mode
+	})
+}
+
+// WithRequestDurationBuckets sets the explicit bucket boundaries, in seconds, of the "http.server.request.duration"
+// histogram. The default boundaries range from 5ms to 10s, which may be too narrow for long-polling or streaming
+// endpoints. The boundaries are converted to milliseconds for the "http.server.duration" histogram of the v1.20.0
+// semantic conventions, see [WithSemConvStabilityOptIn].
+//
+// The boundaries are only an advice to the SDK: a view matching the instrument takes precedence. This is also the way
+// to record a base-2 exponential histogram instead, which does not require boundaries:
+//
+//	sdkmetric.NewMeterProvider(
+//		sdkmetric.WithReader(reader),
+//		sdkmetric.WithView(sdkmetric.NewView(
+//			sdkmetric.Instrument{Name: "http.server.request.duration", Scope: instrumentation.Scope{Name: oteltracing.ScopeName}},
+//			sdkmetric.Stream{Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20}},
+//		)),
+//	)
+func WithRequestDurationBuckets(bounds ...float64) Option {
+	return optionFunc(func(c *config) {
+		c.buckets.duration = bounds
+	})
+}
+
+// WithRequestBodySizeBuckets sets the explicit bucket boundaries, in bytes, of the "http.server.request.body.size"
+// histogram. If not set, the SDK default boundaries are used, which do not go beyond 10kB. See
+// [WithRequestDurationBuckets] to record a base-2 exponential histogram instead.
+func WithRequestBodySizeBuckets(bounds ...float64) Option {
+	return optionFunc(func(c *config) {
+		c.buckets.requestSize = bounds
+	})
+}
+
+// WithResponseBodySizeBuckets sets the explicit bucket boundaries, in bytes, of the "http.server.response.body.size"
+// histogram. If not set, the SDK default boundaries are used, which do not go beyond 10kB. See
+// [WithRequestDurationBuckets] to record a base-2 exponential histogram instead.
+func WithResponseBodySizeBuckets(bounds ...float64) Option {
+	return optionFunc(func(c *config) {
+		c.buckets.responseSize = bounds
+	})
+}
+
+// WithTraceBasedExemplars ensures that the exemplars of the metrics recorded by this package only link to sampled
+// traces. The metrics are always recorded within the context of the request span, so with the default trace-based
+// exemplar filter of the SDK, exemplars are only recorded for sampled requests. This option applies the same rule to
+// the measurements of this package when the SDK is configured with another filter (e.g. exemplar.AlwaysOnFilter):
+// the span context of unsampled requests is removed from the measurement context, so that their exemplars, if any,
+// carry no trace and span ID.
+func WithTraceBasedExemplars() Option {
+	return optionFunc(func(c *config) {
+		c.traceExemplars = true
+	})
+}
+
+// WithMetricsCardinalityLimit sets the maximum number of distinct attribute sets recorded by the request metrics
+// ("http.server.request.duration", "http.server.request.body.size" and "http.server.response.body.size"). Once the
+// limit is reached, measurements with a new attribute set are folded into a single overflow attribute set, holding only
+// the "http.route" attribute set to "_OTHER". The request metrics therefore record at most limit + 1 attribute sets.
+// The number of folded measurements is reported by the "oteltracing.metrics.cardinality.overflow" counter. A limit of
+// zero or less disables the limiter, which is the default.
+func WithMetricsCardinalityLimit(limit int) Option {
+	return optionFunc(func(c *config) {
+		c.cardinality = limit
+	})
+}
+
+// WithBaggageAttributes records the provided W3C baggage members of the incoming request as span attributes, set at
+// span start so that samplers can use them. The attribute key is the member key (e.g. "tenant.id"), and the value is
+// truncated to 128 bytes by default, see [WithBaggageValueLimit]. The baggage is extracted by the configured
+// propagator, which must include [propagation.Baggage] (e.g. with propagation.NewCompositeTextMapPropagator). The
+// baggage of requests handled by a public endpoint (see [WithPublicEndpoint]) comes from untrusted callers, and is
+// never recorded.
+func WithBaggageAttributes(keys ...string) Option {
+	return optionFunc(func(c *config) {
+		if c.baggage == nil {
+			c.baggage = &baggageAttrs{limit: defaultBaggageValueLimit}
+		}
+		c.baggage.span = append(c.baggage.span, keys...)
+	})
+}
+
+// WithBaggageMetricsAttributes records the provided W3C baggage members of the incoming request as metric attributes,
+// like [WithBaggageAttributes] does for spans, and is likewise ignored for public endpoints. Baggage is controlled by the
+// client, so only low cardinality members should be recorded, and combining this option with
+// [WithMetricsCardinalityLimit] is recommended.
+func WithBaggageMetricsAttributes(keys ...string) Option {
+	return optionFunc(func(c *config) {
+		if c.baggage == nil {
+			c.baggage = &baggageAttrs{limit: defaultBaggageValueLimit}
+		}
+		c.baggage.metrics = append(c.baggage.metrics, keys...)
+	})
+}
+
+// WithBaggageValueLimit sets the maximum length, in bytes, of the baggage values recorded with
+// [WithBaggageAttributes] and [WithBaggageMetricsAttributes]. Longer values are truncated. The default is 128 bytes,
+// and a limit of zero or less disables truncation.
+func WithBaggageValueLimit(limit int) Option {
+	return optionFunc(func(c *config) {
+		if c.baggage == nil {
+			c.baggage = &baggageAttrs{}
+		}
+		c.baggage.limit = limit
+	})
+}
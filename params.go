@@ -0,0 +1,52 @@
+package oteltracing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/fox-toolkit/fox"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const routeParamPrefix = "http.route.param."
+
+// RouteParamTransformer is a function that returns the value to record for a route parameter, given its name and
+// matched value. It is used in conjunction with the [WithRouteParamTransformer] middleware option.
+type RouteParamTransformer func(name, value string) string
+
+// HashRouteParam is a [RouteParamTransformer] that records the hex-encoded SHA-256 digest of the value instead of
+// the value itself. This allows correlating requests on the same resource without recording the raw value.
+func HashRouteParam(_, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// routeParams determines which route parameters are recorded as span attributes.
+type routeParams struct {
+	enabled   bool
+	allow     map[string]struct{}
+	deny      map[string]struct{}
+	transform RouteParamTransformer
+}
+
+// attributes returns the "http.route.param.<name>" attributes for the route parameters matched by c. If an allowlist
+// is configured, only the listed parameters are recorded, and the denylist always takes precedence.
+func (rp *routeParams) attributes(c *fox.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for param := range c.Params() {
+		if rp.allow != nil {
+			if _, ok := rp.allow[param.Key]; !ok {
+				continue
+			}
+		}
+		if _, ok := rp.deny[param.Key]; ok {
+			continue
+		}
+		value := param.Value
+		if rp.transform != nil {
+			value = rp.transform(param.Key, value)
+		}
+		attrs = append(attrs, attribute.String(routeParamPrefix+param.Key, value))
+	}
+	return attrs
+}
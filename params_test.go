@@ -0,0 +1,115 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithRouteParams(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want []attribute.KeyValue
+	}{
+		{
+			name: "disabled by default",
+		},
+		{
+			name: "transformer alone does not enable params",
+			opts: []Option{WithRouteParamTransformer(HashRouteParam)},
+		},
+		{
+			name: "all params",
+			opts: []Option{WithRouteParams()},
+			want: []attribute.KeyValue{
+				attribute.String("http.route.param.tenant", "acme"),
+				attribute.String("http.route.param.email", "john@example.com"),
+			},
+		},
+		{
+			name: "allowlist",
+			opts: []Option{WithRouteParams("tenant")},
+			want: []attribute.KeyValue{
+				attribute.String("http.route.param.tenant", "acme"),
+			},
+		},
+		{
+			name: "denylist",
+			opts: []Option{WithRouteParamsDenylist("email")},
+			want: []attribute.KeyValue{
+				attribute.String("http.route.param.tenant", "acme"),
+			},
+		},
+		{
+			name: "denylist takes precedence",
+			opts: []Option{WithRouteParams("tenant", "email"), WithRouteParamsDenylist("email")},
+			want: []attribute.KeyValue{
+				attribute.String("http.route.param.tenant", "acme"),
+			},
+		},
+		{
+			name: "transformer",
+			opts: []Option{
+				WithRouteParams(),
+				WithRouteParamTransformer(func(name, value string) string {
+					if name == "email" {
+						return HashRouteParam(name, value)
+					}
+					return strings.ToUpper(value)
+				}),
+			},
+			want: []attribute.KeyValue{
+				attribute.String("http.route.param.tenant", "ACME"),
+				attribute.String("http.route.param.email", "855f96e983f1f8e8be944692b6f719fd54329826cb62e98015efee8e2e071dd4"),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter))...)),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/tenants/{tenant}/users/{email}", func(c *fox.Context) {})
+			require.NoError(t, err)
+
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tenants/acme/users/john@example.com", nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			var got []attribute.KeyValue
+			for _, attr := range spans[0].Attributes() {
+				if strings.HasPrefix(string(attr.Key), "http.route.param.") {
+					got = append(got, attr)
+				}
+			}
+			assert.ElementsMatch(t, tc.want, got)
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			for _, attr := range hist.DataPoints[0].Attributes.ToSlice() {
+				assert.False(t, strings.HasPrefix(string(attr.Key), "http.route.param."))
+			}
+		})
+	}
+}
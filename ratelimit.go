@@ -0,0 +1,168 @@
+package oteltracing
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SamplingProbabilityKey is the attribute key recorded by the [RateLimitingSampler] on sampled spans. It holds the
+// estimated probability with which the spans of the route were sampled, which can be used to extrapolate the span
+// counts.
+const SamplingProbabilityKey = attribute.Key("sampling.probability")
+
+// RateLimitingSampler returns a [sdktrace.Sampler] that samples at most tracesPerSecond spans per second for each
+// value of the "http.route" attribute set by the [Middleware], using a token bucket that allows bursts of up to
+// tracesPerSecond spans. It protects the collector from a sudden spike on a single route, without affecting the
+// sampling of the other routes. Spans without the "http.route" attribute, like client spans, are sampled with
+// sdktrace.ParentBased(sdktrace.AlwaysSample()).
+//
+// Sampled spans carry the "sampling.probability" attribute, see [SamplingProbabilityKey]. Dropped spans are counted
+// by the "oteltracing.sampler.dropped" metric, recorded with the meter provider configured with [WithMeterProvider].
+// Other options are ignored.
+//
+// The rate limit applies regardless of the parent span, wrap the sampler with [sdktrace.ParentBased] to respect the sampling
+// decision of the caller, or combine it with [RouteSampler] to rate limit selected routes only.
+func RateLimitingSampler(tracesPerSecond float64, opts ...Option) sdktrace.Sampler {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+
+	meter := cfg.meter.Meter(ScopeName, metric.WithInstrumentationVersion(Version))
+	dropped, err := meter.Int64Counter(
+		"oteltracing.sampler.dropped",
+		metric.WithUnit("{span}"),
+		metric.WithDescription("Number of spans dropped by the rate limiting sampler."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &rateLimitingSampler{
+		fallback: sdktrace.ParentBased(sdktrace.AlwaysSample()),
+		dropped:  dropped,
+		buckets:  make(map[string]*tokenBucket),
+		rate:     math.Max(tracesPerSecond, 0),
+		now:      time.Now,
+	}
+}
+
+type rateLimitingSampler struct {
+	fallback sdktrace.Sampler
+	dropped  metric.Int64Counter
+	now      func() time.Time
+	buckets  map[string]*tokenBucket
+	mu       sync.RWMutex
+	rate     float64
+}
+
+// ShouldSample implements [sdktrace.Sampler].
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	route, ok := "", false
+	for _, attr := range p.Attributes {
+		if attr.Key == otelsemconv.HTTPRouteKey {
+			route, ok = attr.Value.AsString(), true
+			break
+		}
+	}
+	if !ok {
+		return s.fallback.ShouldSample(p)
+	}
+
+	tracestate := oteltrace.SpanContextFromContext(p.ParentContext).TraceState()
+	sampled, probability := s.bucket(route).take(s.now(), s.rate)
+	if !sampled {
+		s.dropped.Add(p.ParentContext, 1, metric.WithAttributes(otelsemconv.HTTPRoute(route)))
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: tracestate}
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{SamplingProbabilityKey.Float64(probability)},
+		Tracestate: tracestate,
+	}
+}
+
+// Description implements [sdktrace.Sampler].
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.rate)
+}
+
+// bucket returns the token bucket of the route. Once the number of buckets reaches maxCachedAttributeSets, new routes
+// share the bucket of the overflow route.
+func (s *rateLimitingSampler) bucket(route string) *tokenBucket {
+	s.mu.RLock()
+	b, ok := s.buckets[route]
+	s.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok = s.buckets[route]; ok {
+		return b
+	}
+	if len(s.buckets) >= maxCachedAttributeSets {
+		route = overflowRoute
+		if b, ok = s.buckets[route]; ok {
+			return b
+		}
+	}
+	b = new(tokenBucket)
+	if s.rate > 0 {
+		b.tokens = math.Max(s.rate, 1)
+	}
+	s.buckets[route] = b
+	return b
+}
+
+// tokenBucket holds the tokens of a route, and the number of spans seen in the current and the previous second to
+// estimate the sampling probability.
+type tokenBucket struct {
+	last     time.Time
+	window   time.Time
+	mu       sync.Mutex
+	tokens   float64
+	seen     float64
+	prevSeen float64
+}
+
+// take reports whether a token is available, and returns the estimated sampling probability of the route.
+func (b *tokenBucket) take(now time.Time, rate float64) (bool, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	burst := math.Max(rate, 1)
+	if !b.last.IsZero() {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+
+	switch elapsed := now.Sub(b.window); {
+	case elapsed >= 2*time.Second:
+		b.window, b.seen, b.prevSeen = now, 0, 0
+	case elapsed >= time.Second:
+		b.window, b.seen, b.prevSeen = b.window.Add(time.Second), 0, b.seen
+	}
+	b.seen++
+
+	probability := 1.0
+	if observed := math.Max(b.seen, b.prevSeen); observed > rate {
+		probability = rate / observed
+	}
+
+	if b.tokens < 1 {
+		return false, probability
+	}
+	b.tokens--
+	return true, probability
+}
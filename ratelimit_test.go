@@ -0,0 +1,122 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRateLimitingSampler(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	sampler := RateLimitingSampler(2, WithMeterProvider(meter)).(*rateLimitingSampler)
+	now := time.Unix(0, 0)
+	sampler.now = func() time.Time { return now }
+
+	sample := func(route string) sdktrace.SamplingResult {
+		return sampler.ShouldSample(sdktrace.SamplingParameters{
+			ParentContext: context.Background(),
+			TraceID:       oteltrace.TraceID{0x01},
+			Name:          "span",
+			Attributes:    []attribute.KeyValue{attribute.String("http.route", route)},
+		})
+	}
+
+	// The burst allows 2 spans per route.
+	res := sample("/search")
+	assert.Equal(t, sdktrace.RecordAndSample, res.Decision)
+	assert.Equal(t, []attribute.KeyValue{SamplingProbabilityKey.Float64(1)}, res.Attributes)
+	assert.Equal(t, sdktrace.RecordAndSample, sample("/search").Decision)
+	assert.Equal(t, sdktrace.Drop, sample("/search").Decision)
+	assert.Equal(t, sdktrace.Drop, sample("/search").Decision)
+
+	// Other routes have their own bucket.
+	assert.Equal(t, sdktrace.RecordAndSample, sample("/checkout").Decision)
+
+	// Tokens are refilled over time, and the probability is estimated from the previous second.
+	now = now.Add(time.Second)
+	res = sample("/search")
+	assert.Equal(t, sdktrace.RecordAndSample, res.Decision)
+	assert.Equal(t, []attribute.KeyValue{SamplingProbabilityKey.Float64(0.5)}, res.Attributes)
+
+	// Half a second refills one token.
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, sdktrace.RecordAndSample, sample("/search").Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, sample("/search").Decision)
+	assert.Equal(t, sdktrace.Drop, sample("/search").Decision)
+
+	// Spans without route are sampled by the parent based fallback.
+	res = sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: oteltrace.TraceID{0x01}, Name: "GET"})
+	assert.Equal(t, sdktrace.RecordAndSample, res.Decision)
+	assert.Empty(t, res.Attributes)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	dropped := findMetric(t, rm, "oteltracing.sampler.dropped").Data.(metricdata.Sum[int64])
+	require.Len(t, dropped.DataPoints, 1)
+	assert.Equal(t, int64(3), dropped.DataPoints[0].Value)
+	route, ok := dropped.DataPoints[0].Attributes.Value("http.route")
+	require.True(t, ok)
+	assert.Equal(t, "/search", route.AsString())
+}
+
+func TestRateLimitingSamplerZeroRate(t *testing.T) {
+	sampler := RateLimitingSampler(0)
+	assert.Equal(t, "RateLimitingSampler{0}", sampler.Description())
+	res := sampler.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       oteltrace.TraceID{0x01},
+		Attributes:    []attribute.KeyValue{attribute.String("http.route", "/search")},
+	})
+	assert.Equal(t, sdktrace.Drop, res.Decision)
+}
+
+func TestRateLimitingSamplerKeepsTracestate(t *testing.T) {
+	ts, err := oteltrace.ParseTraceState("vendor=value")
+	require.NoError(t, err)
+	parent := oteltrace.ContextWithSpanContext(context.Background(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0x01},
+		SpanID:     oteltrace.SpanID{0x01},
+		TraceState: ts,
+		Remote:     true,
+	}))
+
+	res := RateLimitingSampler(1).ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: parent,
+		TraceID:       oteltrace.TraceID{0x01},
+		Attributes:    []attribute.KeyValue{attribute.String("http.route", "/search")},
+	})
+	assert.Equal(t, sdktrace.RecordAndSample, res.Decision)
+	assert.Equal(t, ts, res.Tracestate)
+}
+
+func TestRateLimitingSamplerWithMiddleware(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr), sdktrace.WithSampler(RateLimitingSampler(1)))
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider))))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/search", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	for range 5 {
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search", nil))
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), SamplingProbabilityKey.Float64(1))
+}
@@ -0,0 +1,139 @@
+package oteltracing
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redacted is the value recorded in place of sensitive data.
+const Redacted = "REDACTED"
+
+// DefaultRedactor redacts the values of common sensitive headers and query parameters, including credentials,
+// cookies, API keys, tokens, and signatures of AWS, Google Cloud and Azure pre-signed URLs. It is used by [Middleware]
+// and [Transport] unless another [Redactor] is configured with [WithRedactor].
+var DefaultRedactor = NewRedactor(
+	[]string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Auth-Token",
+		"X-Amz-Security-Token",
+	},
+	[]string{
+		"api_key",
+		"apikey",
+		"token",
+		"access_token",
+		"refresh_token",
+		"id_token",
+		"client_secret",
+		"password",
+		"signature",
+		"sig",
+		"AWSAccessKeyId",
+		"X-Amz-Signature",
+		"X-Amz-Credential",
+		"X-Amz-Security-Token",
+		"X-Goog-Signature",
+		"X-Goog-Credential",
+	},
+)
+
+// Redactor determines which header and query parameter values are sensitive, and replaces them before they are
+// recorded as span attributes. It is used in conjunction with the [WithRedactor] option, and applies to the captured
+// request and response headers, as well as to the "url.query" and "url.full" attributes.
+type Redactor interface {
+	// RedactHeader returns the values to record for the header name. The name is in canonical form
+	// (e.g. "Set-Cookie"). Implementations must not modify the values slice.
+	RedactHeader(name string, values []string) []string
+	// RedactQueryParam returns the value to record for the query parameter key.
+	RedactQueryParam(key, value string) string
+}
+
+// NewRedactor returns a [Redactor] that replaces the values of the provided headers and query parameters with
+// [Redacted]. Header names and query parameter keys are matched case-insensitively.
+func NewRedactor(headers, queryParams []string) Redactor {
+	r := redactor{
+		headers: make(map[string]struct{}, len(headers)),
+		params:  make(map[string]struct{}, len(queryParams)),
+	}
+	for _, h := range headers {
+		r.headers[strings.ToLower(h)] = struct{}{}
+	}
+	for _, p := range queryParams {
+		r.params[strings.ToLower(p)] = struct{}{}
+	}
+	return r
+}
+
+type redactor struct {
+	headers map[string]struct{}
+	params  map[string]struct{}
+}
+
+// RedactHeader replaces every value of a sensitive header with [Redacted].
+func (r redactor) RedactHeader(name string, values []string) []string {
+	if _, ok := r.headers[strings.ToLower(name)]; !ok {
+		return values
+	}
+	redacted := make([]string, len(values))
+	for i := range redacted {
+		redacted[i] = Redacted
+	}
+	return redacted
+}
+
+// RedactQueryParam replaces the value of a sensitive query parameter with [Redacted].
+func (r redactor) RedactQueryParam(key, value string) string {
+	if _, ok := r.params[strings.ToLower(key)]; ok {
+		return Redacted
+	}
+	return value
+}
+
+// redactQuery applies the redactor to each parameter of the raw query. The order of the parameters and the encoding
+// of the values that are not redacted is preserved.
+func redactQuery(r Redactor, rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(rawQuery))
+	for i, pair := range strings.Split(rawQuery, "&") {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		rawKey, rawValue, hasValue := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+
+		sb.WriteString(rawKey)
+		if redacted := r.RedactQueryParam(key, value); redacted != value {
+			sb.WriteByte('=')
+			sb.WriteString(url.QueryEscape(redacted))
+			continue
+		}
+		if hasValue {
+			sb.WriteByte('=')
+			sb.WriteString(rawValue)
+		}
+	}
+	return sb.String()
+}
+
+// redactURL returns the string form of u, without the user info and with the query redacted.
+func redactURL(r Redactor, u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = redactQuery(r, u.RawQuery)
+	return redacted.String()
+}
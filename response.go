@@ -0,0 +1,65 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// HeaderTraceResponse is the W3C Trace Context Level 2 response header.
+	HeaderTraceResponse = "traceresponse"
+	// HeaderServerTiming is the Server-Timing response header.
+	HeaderServerTiming = "Server-Timing"
+)
+
+const traceContextVersion = "00"
+
+// ResponsePropagator injects the span context of the server span into the response headers. It is used in conjunction
+// with the [WithResponsePropagation] middleware option. Inject is called before the handler writes the response.
+type ResponsePropagator interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+// The ResponsePropagatorFunc type is an adapter to allow the use of ordinary functions as [ResponsePropagator].
+type ResponsePropagatorFunc func(ctx context.Context, header http.Header)
+
+// Inject calls f(ctx, header).
+func (f ResponsePropagatorFunc) Inject(ctx context.Context, header http.Header) {
+	f(ctx, header)
+}
+
+// TraceResponsePropagator is a [ResponsePropagator] that sets the W3C "traceresponse" header, as defined by the
+// Trace Context Level 2 specification (e.g. traceresponse: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01).
+type TraceResponsePropagator struct{}
+
+// Inject sets the "traceresponse" header if ctx holds a valid span context.
+func (TraceResponsePropagator) Inject(ctx context.Context, header http.Header) {
+	if value := traceParent(ctx); value != "" {
+		header.Set(HeaderTraceResponse, value)
+	}
+}
+
+// ServerTimingPropagator is a [ResponsePropagator] that adds a "traceparent" metric to the "Server-Timing" header
+// (e.g. Server-Timing: traceparent;desc="00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"). Unlike the
+// "traceresponse" header, the "Server-Timing" header is exposed to browser RUM agents through the Resource Timing API
+// (cross-origin requests also require the "Timing-Allow-Origin" header).
+type ServerTimingPropagator struct{}
+
+// Inject adds the "traceparent" metric to the "Server-Timing" header if ctx holds a valid span context.
+func (ServerTimingPropagator) Inject(ctx context.Context, header http.Header) {
+	if value := traceParent(ctx); value != "" {
+		header.Add(HeaderServerTiming, `traceparent;desc="`+value+`"`)
+	}
+}
+
+// traceParent formats the span context of ctx using the W3C traceparent format, or returns an empty string if ctx
+// does not hold a valid span context.
+func traceParent(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return traceContextVersion + "-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+}
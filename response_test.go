@@ -0,0 +1,73 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWithResponsePropagation(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithTracerProvider(provider),
+			WithResponsePropagation(
+				TraceResponsePropagator{},
+				ServerTimingPropagator{},
+				ResponsePropagatorFunc(func(ctx context.Context, header http.Header) {
+					header.Set("X-Trace-Id", traceParent(ctx)[3:35])
+				}),
+			),
+		)),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/hello", func(c *fox.Context) {
+		c.Writer().Header().Add(HeaderServerTiming, "db;dur=53")
+		_ = c.String(http.StatusOK, "hello")
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	sc := spans[0].SpanContext()
+	want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"
+
+	assert.Equal(t, want, w.Header().Get(HeaderTraceResponse))
+	assert.Equal(t, []string{`traceparent;desc="` + want + `"`, "db;dur=53"}, w.Header().Values(HeaderServerTiming))
+	assert.Equal(t, sc.TraceID().String(), w.Header().Get("X-Trace-Id"))
+}
+
+func TestWithResponsePropagationInvalidSpanContext(t *testing.T) {
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithTracerProvider(noop.NewTracerProvider()),
+			WithResponsePropagation(TraceResponsePropagator{}, ServerTimingPropagator{}),
+		)),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/hello", func(c *fox.Context) {
+		_ = c.String(http.StatusOK, "hello")
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	assert.Empty(t, w.Header().Get(HeaderTraceResponse))
+	assert.Empty(t, w.Header().Get(HeaderServerTiming))
+}
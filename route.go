@@ -0,0 +1,98 @@
+package oteltracing
+
+import (
+	"github.com/fox-toolkit/fox"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type routeConfigKey struct{}
+
+// RouteOption configures the [Middleware] for a specific route. Route options are attached to a route with
+// [RouteConfig], and take precedence over the middleware options for this route.
+type RouteOption interface {
+	applyRoute(*routeConfig)
+}
+
+type routeOptionFunc func(*routeConfig)
+
+func (o routeOptionFunc) applyRoute(c *routeConfig) {
+	o(c)
+}
+
+type routeConfig struct {
+	spanName       string
+	statusFn       SpanStatusClassifier
+	spanAttrs      []attribute.KeyValue
+	metricAttrs    []attribute.KeyValue
+	disableTracing bool
+	disableMetrics bool
+}
+
+// RouteConfig returns a [fox.RouteOption] that annotates the route with the provided route options. The [Middleware]
+// reads the annotation at request time, so the same route options apply to every middleware instance.
+//
+//	f.MustAdd(fox.MethodGet, "/healthz", handler, oteltracing.RouteConfig(oteltracing.WithoutRouteTracing()))
+func RouteConfig(opts ...RouteOption) fox.RouteOption {
+	cfg := new(routeConfig)
+	for _, opt := range opts {
+		opt.applyRoute(cfg)
+	}
+	return fox.WithAnnotation(routeConfigKey{}, cfg)
+}
+
+// routeConfigFrom returns the route configuration of the matched route, or nil if the route has none or if the handler
+// is called in a scope other than [fox.RouteHandler].
+func routeConfigFrom(c *fox.Context) *routeConfig {
+	route := c.Route()
+	if route == nil {
+		return nil
+	}
+	cfg, _ := route.Annotation(routeConfigKey{}).(*routeConfig)
+	return cfg
+}
+
+// WithoutRouteTracing disables tracing for the route. Metrics are still recorded, unless disabled with
+// [WithoutRouteMetrics].
+func WithoutRouteTracing() RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		c.disableTracing = true
+	})
+}
+
+// WithoutRouteMetrics disables metrics for the route. Requests are still traced, unless disabled with
+// [WithoutRouteTracing].
+func WithoutRouteMetrics() RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		c.disableMetrics = true
+	})
+}
+
+// WithRouteSpanName overrides the span name for the route.
+func WithRouteSpanName(name string) RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		c.spanName = name
+	})
+}
+
+// WithRouteSpanAttributes adds static attributes to the spans of the route.
+func WithRouteSpanAttributes(attrs ...attribute.KeyValue) RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		c.spanAttrs = append(c.spanAttrs, attrs...)
+	})
+}
+
+// WithRouteMetricsAttributes adds static attributes to the metrics recorded for the route.
+func WithRouteMetricsAttributes(attrs ...attribute.KeyValue) RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		c.metricAttrs = append(c.metricAttrs, attrs...)
+	})
+}
+
+// WithRouteSpanStatusClassifier overrides the [SpanStatusClassifier] for the route.
+func WithRouteSpanStatusClassifier(fn SpanStatusClassifier) RouteOption {
+	return routeOptionFunc(func(c *routeConfig) {
+		if fn != nil {
+			c.statusFn = fn
+		}
+	})
+}
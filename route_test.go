@@ -0,0 +1,120 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRouteConfig(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter))),
+	)
+	require.NoError(t, err)
+
+	var spanCtx oteltrace.SpanContext
+	_, err = f.Add(fox.MethodGet, "/healthz", func(c *fox.Context) {
+		spanCtx = oteltrace.SpanContextFromContext(c.Request().Context())
+	}, RouteConfig(WithoutRouteTracing()))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/internal", func(c *fox.Context) {}, RouteConfig(WithoutRouteMetrics()))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/noop", func(c *fox.Context) {}, RouteConfig(WithoutRouteTracing(), WithoutRouteMetrics()))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+		c.Writer().WriteHeader(http.StatusNotFound)
+	}, RouteConfig(
+		WithRouteSpanName("get user"),
+		WithRouteSpanAttributes(attribute.String("team", "identity")),
+		WithRouteMetricsAttributes(attribute.String("tier", "gold")),
+		WithRouteSpanStatusClassifier(ClientErrorSpanStatusClassifier),
+	))
+	require.NoError(t, err)
+
+	t.Run("disable tracing", func(t *testing.T) {
+		sr.Reset()
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Empty(t, sr.Ended())
+		assert.False(t, spanCtx.IsValid())
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, hist.DataPoints, 1)
+		route, ok := hist.DataPoints[0].Attributes.Value("http.route")
+		require.True(t, ok)
+		assert.Equal(t, "/healthz", route.AsString())
+	})
+
+	t.Run("disable metrics", func(t *testing.T) {
+		sr.Reset()
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/internal", nil))
+		require.Len(t, sr.Ended(), 1)
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		for _, dp := range hist.DataPoints {
+			route, _ := dp.Attributes.Value("http.route")
+			assert.NotEqual(t, "/internal", route.AsString())
+		}
+	})
+
+	t.Run("disable tracing and metrics", func(t *testing.T) {
+		sr.Reset()
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/noop", nil))
+		assert.Empty(t, sr.Ended())
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		for _, dp := range hist.DataPoints {
+			route, _ := dp.Attributes.Value("http.route")
+			assert.NotEqual(t, "/noop", route.AsString())
+		}
+	})
+
+	t.Run("span name, attributes and status classifier", func(t *testing.T) {
+		sr.Reset()
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+		spans := sr.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "get user", spans[0].Name())
+		assert.Contains(t, spans[0].Attributes(), attribute.String("team", "identity"))
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+		var found bool
+		for _, dp := range hist.DataPoints {
+			if route, _ := dp.Attributes.Value("http.route"); route.AsString() != "/users/{id}" {
+				continue
+			}
+			found = true
+			tier, ok := dp.Attributes.Value("tier")
+			require.True(t, ok)
+			assert.Equal(t, "gold", tier.AsString())
+			_, ok = dp.Attributes.Value("team")
+			assert.False(t, ok)
+		}
+		assert.True(t, found)
+	})
+}
@@ -0,0 +1,113 @@
+package oteltracing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// oldMethodKey is the "http.method" attribute key of the HTTP semantic conventions v1.20.0.
+const oldMethodKey = attribute.Key("http.method")
+
+// SamplingRule associates a [sdktrace.Sampler] with the spans of a route. It is used in conjunction with
+// [RouteSampler].
+type SamplingRule struct {
+	// Method restricts the rule to a request method (e.g. "GET"). An empty method matches any method.
+	Method string
+	// Route is matched against the "http.route" attribute, which holds the fox route pattern (e.g. "/users/{id}").
+	// A trailing "*" matches any route starting with the preceding prefix (e.g. "/checkout/*"), and "*" alone
+	// matches any route.
+	Route string
+	// Sampler makes the sampling decision for the matching spans (e.g. sdktrace.TraceIDRatioBased(0.01)).
+	Sampler sdktrace.Sampler
+}
+
+// matches reports whether the rule applies to the method and route.
+func (r SamplingRule) matches(method, route string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Route, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return r.Route == route
+}
+
+// RouteSampler returns a [sdktrace.Sampler] that delegates the sampling decision to the sampler of the first rule
+// matching the "http.route" and request method attributes of the span. The attributes are set at span start by the
+// [Middleware], so the rules apply to server spans. Spans matching no rule, or without the "http.route" attribute,
+// are sampled by the fallback sampler, which defaults to sdktrace.ParentBased(sdktrace.AlwaysSample()) if nil. The
+// rules are copied, and RouteSampler panics if the sampler of a rule is nil.
+//
+// The rules apply regardless of the parent span, wrap the rule sampler with [sdktrace.ParentBased] to respect the
+// sampling decision of the caller:
+//
+//	oteltracing.RouteSampler([]oteltracing.SamplingRule{
+//		{Route: "/checkout/*", Sampler: sdktrace.AlwaysSample()},
+//		{Route: "/search", Sampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.01))},
+//	}, nil)
+func RouteSampler(rules []SamplingRule, fallback sdktrace.Sampler) sdktrace.Sampler {
+	for i, rule := range rules {
+		if rule.Sampler == nil {
+			panic(fmt.Sprintf("oteltracing: nil sampler in sampling rule %d (%s %s)", i, rule.Method, rule.Route))
+		}
+	}
+	if fallback == nil {
+		fallback = sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	return routeSampler{
+		rules:    slices.Clone(rules),
+		fallback: fallback,
+	}
+}
+
+type routeSampler struct {
+	fallback sdktrace.Sampler
+	rules    []SamplingRule
+}
+
+// ShouldSample implements [sdktrace.Sampler].
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	var (
+		route, method string
+		hasRoute      bool
+	)
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case otelsemconv.HTTPRouteKey:
+			route, hasRoute = attr.Value.AsString(), true
+		case otelsemconv.HTTPRequestMethodKey, oldMethodKey:
+			method = attr.Value.AsString()
+		}
+	}
+
+	if hasRoute {
+		for _, rule := range s.rules {
+			if rule.matches(method, route) {
+				return rule.Sampler.ShouldSample(p)
+			}
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description implements [sdktrace.Sampler].
+func (s routeSampler) Description() string {
+	var sb strings.Builder
+	sb.WriteString("RouteSampler{")
+	for _, rule := range s.rules {
+		method := rule.Method
+		if method == "" {
+			method = "*"
+		}
+		fmt.Fprintf(&sb, "%s %s:%s,", method, rule.Route, rule.Sampler.Description())
+	}
+	sb.WriteString("fallback:")
+	sb.WriteString(s.fallback.Description())
+	sb.WriteByte('}')
+	return sb.String()
+}
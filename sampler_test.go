@@ -0,0 +1,126 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRouteSampler(t *testing.T) {
+	sampler := RouteSampler([]SamplingRule{
+		{Method: http.MethodPost, Route: "/checkout/*", Sampler: sdktrace.AlwaysSample()},
+		{Route: "/search", Sampler: sdktrace.NeverSample()},
+		{Route: "/checkout/*", Sampler: sdktrace.NeverSample()},
+	}, sdktrace.AlwaysSample())
+
+	cases := []struct {
+		name  string
+		attrs []attribute.KeyValue
+		want  sdktrace.SamplingDecision
+	}{
+		{
+			name:  "exact route",
+			attrs: []attribute.KeyValue{attribute.String("http.request.method", "GET"), attribute.String("http.route", "/search")},
+			want:  sdktrace.Drop,
+		},
+		{
+			name:  "prefix route with method",
+			attrs: []attribute.KeyValue{attribute.String("http.request.method", "POST"), attribute.String("http.route", "/checkout/{id}")},
+			want:  sdktrace.RecordAndSample,
+		},
+		{
+			name:  "prefix route with other method",
+			attrs: []attribute.KeyValue{attribute.String("http.request.method", "GET"), attribute.String("http.route", "/checkout/{id}")},
+			want:  sdktrace.Drop,
+		},
+		{
+			name:  "old semantic conventions method",
+			attrs: []attribute.KeyValue{attribute.String("http.method", "POST"), attribute.String("http.route", "/checkout/{id}")},
+			want:  sdktrace.RecordAndSample,
+		},
+		{
+			name:  "no matching rule",
+			attrs: []attribute.KeyValue{attribute.String("http.request.method", "GET"), attribute.String("http.route", "/searches")},
+			want:  sdktrace.RecordAndSample,
+		},
+		{
+			name:  "no route",
+			attrs: []attribute.KeyValue{attribute.String("http.request.method", "GET")},
+			want:  sdktrace.RecordAndSample,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := sampler.ShouldSample(sdktrace.SamplingParameters{
+				TraceID:    oteltrace.TraceID{0x01},
+				Name:       "span",
+				Kind:       oteltrace.SpanKindServer,
+				Attributes: tc.attrs,
+			})
+			assert.Equal(t, tc.want, res.Decision)
+		})
+	}
+}
+
+func TestRouteSamplerDefaultFallback(t *testing.T) {
+	sampler := RouteSampler([]SamplingRule{{Route: "*", Sampler: sdktrace.AlwaysSample()}}, nil)
+	assert.Equal(t, "RouteSampler{* *:AlwaysOnSampler,fallback:ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}}", sampler.Description())
+
+	// Spans without route, like client spans, are sampled by the fallback, which respects the parent decision.
+	parent := oteltrace.ContextWithSpanContext(t.Context(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID: oteltrace.TraceID{0x01},
+		SpanID:  oteltrace.SpanID{0x01},
+		Remote:  true,
+	}))
+	res := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: parent, TraceID: oteltrace.TraceID{0x01}, Name: "GET"})
+	assert.Equal(t, sdktrace.Drop, res.Decision)
+}
+
+func TestRouteSamplerWithMiddleware(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(sr),
+		sdktrace.WithSampler(RouteSampler([]SamplingRule{
+			{Route: "/search", Sampler: sdktrace.NeverSample()},
+		}, nil)),
+	)
+
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider))))
+	require.NoError(t, err)
+	for _, pattern := range []string{"/search", "/checkout/{id}"} {
+		_, err = f.Add(fox.MethodGet, pattern, func(c *fox.Context) {})
+		require.NoError(t, err)
+	}
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search", nil))
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/checkout/123", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /checkout/{id}", spans[0].Name())
+}
+
+func TestRouteSamplerRules(t *testing.T) {
+	assert.PanicsWithValue(t, "oteltracing: nil sampler in sampling rule 1 (GET /search)", func() {
+		RouteSampler([]SamplingRule{
+			{Route: "/checkout/*", Sampler: sdktrace.AlwaysSample()},
+			{Method: http.MethodGet, Route: "/search"},
+		}, nil)
+	})
+
+	rules := []SamplingRule{{Route: "/search", Sampler: sdktrace.NeverSample()}}
+	sampler := RouteSampler(rules, sdktrace.AlwaysSample())
+	rules[0].Sampler = nil
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "RouteSampler{* /search:AlwaysOffSampler,fallback:AlwaysOnSampler}", sampler.Description())
+	})
+}
@@ -0,0 +1,50 @@
+package oteltracing
+
+import (
+	"github.com/fox-toolkit/fox"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HandlerScopeKey is the attribute key recorded on server spans to identify the [fox.HandlerScope] in which the
+// request was handled. Since the attribute is set at span start, samplers can use it to make scope-aware sampling
+// decisions (e.g. sample requests handled by the NoRoute handler at a lower rate).
+const HandlerScopeKey = attribute.Key("fox.handler.scope")
+
+// scopeName returns the "fox.handler.scope" attribute value for the scope.
+func scopeName(scope fox.HandlerScope) string {
+	switch scope {
+	case fox.RouteHandler:
+		return "route"
+	case fox.NoRouteHandler:
+		return "no_route"
+	case fox.NoMethodHandler:
+		return "no_method"
+	case fox.RedirectSlashHandler:
+		return "redirect_slash"
+	case fox.RedirectPathHandler:
+		return "redirect_path"
+	case fox.OptionsHandler:
+		return "options"
+	default:
+		return "unknown"
+	}
+}
+
+// scopeSpanName returns the default span name for requests that are not handled by a route handler, or an empty
+// string for the route handler scope.
+func scopeSpanName(method string, scope fox.HandlerScope) string {
+	switch scope {
+	case fox.NoRouteHandler:
+		return method + " not found"
+	case fox.NoMethodHandler:
+		return method + " method not allowed"
+	case fox.RedirectSlashHandler:
+		return method + " redirect slash"
+	case fox.RedirectPathHandler:
+		return method + " redirect path"
+	case fox.OptionsHandler:
+		return method + " automatic"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,173 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareHandlerScope(t *testing.T) {
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		wantScope string
+		wantName  string
+	}{
+		{
+			name:      "route",
+			method:    http.MethodGet,
+			path:      "/users/123",
+			wantScope: "route",
+			wantName:  "GET /users/{id}",
+		},
+		{
+			name:      "no route",
+			method:    http.MethodGet,
+			path:      "/foo",
+			wantScope: "no_route",
+			wantName:  "GET not found",
+		},
+		{
+			name:      "no method",
+			method:    http.MethodPost,
+			path:      "/users/123",
+			wantScope: "no_method",
+			wantName:  "POST method not allowed",
+		},
+		{
+			name:      "redirect slash",
+			method:    http.MethodGet,
+			path:      "/users/123/",
+			wantScope: "redirect_slash",
+			wantName:  "GET redirect slash",
+		},
+		{
+			name:      "redirect path",
+			method:    http.MethodGet,
+			path:      "/users//123",
+			wantScope: "redirect_path",
+			wantName:  "GET redirect path",
+		},
+		{
+			name:      "automatic options",
+			method:    http.MethodOptions,
+			path:      "/users/123",
+			wantScope: "options",
+			wantName:  "OPTIONS automatic",
+		},
+	}
+
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider))),
+		fox.WithNoMethod(true),
+		fox.WithAutoOptions(true),
+		fox.WithHandleTrailingSlash(fox.RedirectSlash),
+		fox.WithHandleFixedPath(fox.RedirectPath),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr.Reset()
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.wantName, spans[0].Name())
+			assert.Contains(t, spans[0].Attributes(), HandlerScopeKey.String(tc.wantScope))
+		})
+	}
+}
+
+func TestMiddlewareEmptySpanNameFallback(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithTracerProvider(provider),
+			WithSpanNameFormatter(func(c *fox.Context) string { return "" }),
+		)),
+		fox.WithNoMethod(true),
+		fox.WithHandleTrailingSlash(fox.RedirectSlash),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	cases := []struct {
+		method   string
+		path     string
+		wantName string
+	}{
+		{method: http.MethodGet, path: "/users/123", wantName: "GET"},
+		{method: "PURGE", path: "/users/123", wantName: "HTTP method not allowed"},
+		{method: http.MethodGet, path: "/foo", wantName: "GET not found"},
+		{method: http.MethodGet, path: "/users/123/", wantName: "GET redirect slash"},
+	}
+	for _, tc := range cases {
+		sr.Reset()
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+
+		spans := sr.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, tc.wantName, spans[0].Name())
+	}
+}
+
+func TestMiddlewareSkipScopes(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware(
+			"foobar",
+			WithTracerProvider(provider),
+			WithMeterProvider(meter),
+			WithSkipTracing(fox.NoRouteHandler|fox.RedirectSlashHandler),
+			WithSkipMetrics(fox.RedirectSlashHandler),
+		)),
+		fox.WithHandleTrailingSlash(fox.RedirectSlash),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	for _, path := range []string{"/users/123", "/foo", "/users/123/"} {
+		f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /users/{id}", spans[0].Name())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 2)
+	statuses := make([]int64, 0, len(hist.DataPoints))
+	for _, dp := range hist.DataPoints {
+		status, ok := dp.Attributes.Value("http.response.status_code")
+		require.True(t, ok)
+		statuses = append(statuses, status.AsInt64())
+	}
+	assert.ElementsMatch(t, []int64{http.StatusOK, http.StatusNotFound}, statuses)
+}
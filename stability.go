@@ -0,0 +1,45 @@
+package oteltracing
+
+import (
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+)
+
+// SemConvMode selects the HTTP semantic conventions emitted by the [Middleware]. It is used in conjunction with the
+// [WithSemConvStabilityOptIn] option.
+type SemConvMode uint8
+
+const (
+	// SemConvNew emits the stable HTTP semantic conventions only (e.g. "http.request.method",
+	// "http.response.status_code" and the "http.server.request.duration" metric, in seconds). This is the default.
+	SemConvNew SemConvMode = iota + 1
+	// SemConvOld emits the HTTP semantic conventions v1.20.0 only (e.g. "http.method", "http.status_code" and the
+	// "http.server.duration" metric, in milliseconds). The query is recorded as part of the "http.target" attribute.
+	// Since the v1.20.0 conventions have no equivalent, the "error.type" attribute of the stable conventions is still
+	// recorded on the span and the metrics when the request fails (see [RecordError] and [SetErrorType]).
+	SemConvOld
+	// SemConvDup emits both the stable and the v1.20.0 HTTP semantic conventions, to ease the migration of dashboards
+	// and alerts. Note that the "http.server.active_requests" metric is shared by both conventions, so it is only
+	// recorded with the stable attributes.
+	SemConvDup
+)
+
+// SemConvStabilityOptInEnv is the environment variable read when no mode is configured with
+// [WithSemConvStabilityOptIn]. The value is a comma-separated list. Like other OpenTelemetry instrumentations, the
+// value "http/dup" selects [SemConvDup]. Since the stable conventions are emitted by default, the non-standard value
+// "http/old" selects [SemConvOld]. If both are present, "http/dup" takes precedence, and any other value selects
+// [SemConvNew].
+const SemConvStabilityOptInEnv = semconv.OTelSemConvStabilityOptIn
+
+// mode returns the internal semconv mode, reading the environment if m is unset.
+func (m SemConvMode) mode() semconv.Mode {
+	switch m {
+	case SemConvNew:
+		return semconv.ModeNew
+	case SemConvOld:
+		return semconv.ModeOld
+	case SemConvDup:
+		return semconv.ModeDup
+	default:
+		return semconv.ModeFromEnv()
+	}
+}
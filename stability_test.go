@@ -0,0 +1,160 @@
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithSemConvStabilityOptIn(t *testing.T) {
+	cases := []struct {
+		name        string
+		env         string
+		opts        []Option
+		wantAttrs   []attribute.Key
+		noAttrs     []attribute.Key
+		wantMetrics []string
+		noMetrics   []string
+	}{
+		{
+			name:        "default",
+			wantAttrs:   []attribute.Key{"http.request.method", "http.response.status_code"},
+			noAttrs:     []attribute.Key{"http.method", "http.status_code"},
+			wantMetrics: []string{"http.server.request.duration"},
+			noMetrics:   []string{"http.server.duration"},
+		},
+		{
+			name:        "old",
+			opts:        []Option{WithSemConvStabilityOptIn(SemConvOld)},
+			wantAttrs:   []attribute.Key{"http.method", "http.status_code", "net.host.name", "http.target"},
+			noAttrs:     []attribute.Key{"http.request.method", "http.response.status_code"},
+			wantMetrics: []string{"http.server.duration", "http.server.request.size", "http.server.response.size"},
+			noMetrics:   []string{"http.server.request.duration"},
+		},
+		{
+			name:        "dup",
+			opts:        []Option{WithSemConvStabilityOptIn(SemConvDup)},
+			wantAttrs:   []attribute.Key{"http.request.method", "http.response.status_code", "http.method", "http.status_code"},
+			wantMetrics: []string{"http.server.request.duration", "http.server.duration"},
+		},
+		{
+			name:        "dup from env",
+			env:         "database, http/dup",
+			wantAttrs:   []attribute.Key{"http.request.method", "http.method"},
+			wantMetrics: []string{"http.server.request.duration", "http.server.duration"},
+		},
+		{
+			name:        "old from env",
+			env:         "http/old",
+			wantAttrs:   []attribute.Key{"http.method", "http.status_code"},
+			noAttrs:     []attribute.Key{"http.request.method", "http.response.status_code"},
+			wantMetrics: []string{"http.server.duration"},
+			noMetrics:   []string{"http.server.request.duration"},
+		},
+		{
+			name:        "dup takes precedence over old in env",
+			env:         "http/old,http/dup",
+			wantAttrs:   []attribute.Key{"http.request.method", "http.method"},
+			wantMetrics: []string{"http.server.request.duration", "http.server.duration"},
+		},
+		{
+			name:        "option takes precedence over env",
+			env:         "http/dup",
+			opts:        []Option{WithSemConvStabilityOptIn(SemConvNew)},
+			wantAttrs:   []attribute.Key{"http.request.method"},
+			noAttrs:     []attribute.Key{"http.method"},
+			wantMetrics: []string{"http.server.request.duration"},
+			noMetrics:   []string{"http.server.duration"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(SemConvStabilityOptInEnv, tc.env)
+
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", append(tc.opts, WithTracerProvider(provider), WithMeterProvider(meter))...)),
+			)
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+				_ = c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, err)
+
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			keys := make(map[attribute.Key]struct{})
+			for _, attr := range spans[0].Attributes() {
+				keys[attr.Key] = struct{}{}
+			}
+			for _, key := range tc.wantAttrs {
+				assert.Contains(t, keys, key)
+			}
+			for _, key := range tc.noAttrs {
+				assert.NotContains(t, keys, key)
+			}
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			require.Len(t, rm.ScopeMetrics, 1)
+			names := make(map[string]struct{})
+			for _, m := range rm.ScopeMetrics[0].Metrics {
+				names[m.Name] = struct{}{}
+			}
+			for _, name := range tc.wantMetrics {
+				assert.Contains(t, names, name)
+			}
+			for _, name := range tc.noMetrics {
+				assert.NotContains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestOldServerDurationUnit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	f, err := fox.NewRouter(
+		fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter), WithSemConvStabilityOptIn(SemConvOld))),
+	)
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {})
+	require.NoError(t, err)
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	m := findMetric(t, rm, "http.server.duration")
+	assert.Equal(t, "ms", m.Unit)
+	hist := m.Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 1)
+	attrs := hist.DataPoints[0].Attributes
+	method, ok := attrs.Value("http.method")
+	require.True(t, ok)
+	assert.Equal(t, "GET", method.AsString())
+	route, ok := attrs.Value("http.route")
+	require.True(t, ok)
+	assert.Equal(t, "/users/{id}", route.AsString())
+	status, ok := attrs.Value("http.status_code")
+	require.True(t, ok)
+	assert.Equal(t, int64(http.StatusOK), status.AsInt64())
+}
@@ -0,0 +1,71 @@
+package oteltracing
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SpanStatusClassifier is a function that determines the span status code and description given the response
+// status code. It is used in conjunction with the [WithSpanStatusClassifier] middleware option.
+type SpanStatusClassifier func(c *fox.Context, status int) (codes.Code, string)
+
+// DefaultSpanStatusClassifier classifies the span status according to the OpenTelemetry semantic conventions
+// for HTTP servers. Only 5xx and invalid status codes are reported as errors.
+func DefaultSpanStatusClassifier(_ *fox.Context, status int) (codes.Code, string) {
+	return semconv.HTTPServer{}.Status(status)
+}
+
+// ClientErrorSpanStatusClassifier classifies both 4xx and 5xx status codes as errors, as well as invalid
+// status codes.
+func ClientErrorSpanStatusClassifier(_ *fox.Context, status int) (codes.Code, string) {
+	if status < 100 || status >= 600 {
+		return codes.Error, fmt.Sprintf("Invalid HTTP status code %d", status)
+	}
+	if status >= 400 {
+		return codes.Error, ""
+	}
+	return codes.Unset, ""
+}
+
+// StatusCodeSpanStatusClassifier returns a [SpanStatusClassifier] that classifies the status codes present in
+// the overrides table with the associated span status code, and delegates to fallback for any other status code.
+// If fallback is nil, [DefaultSpanStatusClassifier] is used. The overrides table is copied. For example, the
+// following classifier reports 408 and 429 as errors, but not 501:
+//
+//	StatusCodeSpanStatusClassifier(map[int]codes.Code{
+//		http.StatusRequestTimeout:  codes.Error,
+//		http.StatusTooManyRequests: codes.Error,
+//		http.StatusNotImplemented:  codes.Unset,
+//	}, nil)
+func StatusCodeSpanStatusClassifier(overrides map[int]codes.Code, fallback SpanStatusClassifier) SpanStatusClassifier {
+	if fallback == nil {
+		fallback = DefaultSpanStatusClassifier
+	}
+	overrides = maps.Clone(overrides)
+	return func(c *fox.Context, status int) (codes.Code, string) {
+		if code, ok := overrides[status]; ok {
+			return code, ""
+		}
+		return fallback(c, status)
+	}
+}
+
+// RouteSpanStatusClassifier returns a [SpanStatusClassifier] that delegates to the classifier registered for the
+// matched route pattern in the routes table, and to fallback for any other route. If fallback is nil,
+// [DefaultSpanStatusClassifier] is used. The routes table is copied.
+func RouteSpanStatusClassifier(routes map[string]SpanStatusClassifier, fallback SpanStatusClassifier) SpanStatusClassifier {
+	if fallback == nil {
+		fallback = DefaultSpanStatusClassifier
+	}
+	routes = maps.Clone(routes)
+	return func(c *fox.Context, status int) (codes.Code, string) {
+		if classifier, ok := routes[c.Pattern()]; ok && classifier != nil {
+			return classifier(c, status)
+		}
+		return fallback(c, status)
+	}
+}
@@ -0,0 +1,103 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithSpanStatusClassifier(t *testing.T) {
+	overrides := StatusCodeSpanStatusClassifier(map[int]codes.Code{
+		http.StatusRequestTimeout:  codes.Error,
+		http.StatusTooManyRequests: codes.Error,
+		http.StatusNotImplemented:  codes.Unset,
+	}, nil)
+
+	cases := []struct {
+		name       string
+		classifier SpanStatusClassifier
+		path       string
+		status     int
+		want       codes.Code
+	}{
+		{name: "default 4xx", classifier: DefaultSpanStatusClassifier, path: "/foo", status: http.StatusNotFound, want: codes.Unset},
+		{name: "default 5xx", classifier: DefaultSpanStatusClassifier, path: "/foo", status: http.StatusBadGateway, want: codes.Error},
+		{name: "client error 4xx", classifier: ClientErrorSpanStatusClassifier, path: "/foo", status: http.StatusNotFound, want: codes.Error},
+		{name: "client error 2xx", classifier: ClientErrorSpanStatusClassifier, path: "/foo", status: http.StatusOK, want: codes.Unset},
+		{name: "override 429", classifier: overrides, path: "/foo", status: http.StatusTooManyRequests, want: codes.Error},
+		{name: "override 501", classifier: overrides, path: "/foo", status: http.StatusNotImplemented, want: codes.Unset},
+		{name: "override fallback", classifier: overrides, path: "/foo", status: http.StatusInternalServerError, want: codes.Error},
+		{
+			name: "route table match",
+			classifier: RouteSpanStatusClassifier(map[string]SpanStatusClassifier{
+				"/bar": ClientErrorSpanStatusClassifier,
+			}, nil),
+			path:   "/bar",
+			status: http.StatusConflict,
+			want:   codes.Error,
+		},
+		{
+			name: "route table fallback",
+			classifier: RouteSpanStatusClassifier(map[string]SpanStatusClassifier{
+				"/bar": ClientErrorSpanStatusClassifier,
+			}, nil),
+			path:   "/foo",
+			status: http.StatusConflict,
+			want:   codes.Unset,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithSpanStatusClassifier(tc.classifier))),
+			)
+			require.NoError(t, err)
+			for _, path := range []string{"/foo", "/bar"} {
+				_, err = f.Add(fox.MethodGet, path, func(c *fox.Context) {
+					c.Writer().WriteHeader(tc.status)
+				})
+				require.NoError(t, err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, r)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.want, spans[0].Status().Code)
+		})
+	}
+}
+
+func TestSpanStatusClassifierCopiesTables(t *testing.T) {
+	overrides := map[int]codes.Code{http.StatusTooManyRequests: codes.Error}
+	byStatus := StatusCodeSpanStatusClassifier(overrides, nil)
+	overrides[http.StatusTooManyRequests] = codes.Unset
+	code, _ := byStatus(nil, http.StatusTooManyRequests)
+	assert.Equal(t, codes.Error, code)
+
+	routes := map[string]SpanStatusClassifier{"/users/{id}": ClientErrorSpanStatusClassifier}
+	byRoute := RouteSpanStatusClassifier(routes, nil)
+	delete(routes, "/users/{id}")
+
+	f, err := fox.NewRouter()
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/users/{id}", func(c *fox.Context) {
+		code, _ := byRoute(c, http.StatusNotFound)
+		assert.Equal(t, codes.Error, code)
+	})
+	require.NoError(t, err)
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+}
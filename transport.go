@@ -0,0 +1,165 @@
+package oteltracing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+
+	"github.com/fox-toolkit/oteltracing/internal/semconv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	otelsemconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// Transport implements [http.RoundTripper]. It creates a client span for each outgoing request, injects the span
+// context into the request headers using the configured propagator, and records the "http.client.request.duration" and
+// "http.client.request.body.size" metrics. Use it to link the traces of downstream calls made from Fox handlers,
+// by passing the handler's request context to the outgoing request.
+type Transport struct {
+	base           http.RoundTripper
+	tracer         oteltrace.Tracer
+	propagator     propagation.TextMapPropagator
+	carrier        func(r *http.Request) propagation.TextMapCarrier
+	spanOpts       []oteltrace.SpanStartOption
+	sc             semconv.Client
+	reqHeaders     []capturedHeader
+	resHeaders     []capturedHeader
+	redactor       Redactor
+	traceEvents    bool
+	connMetric     bool
+	traceExemplars bool
+}
+
+// NewTransport wraps the provided [http.RoundTripper] with one that instruments outgoing requests. If base is nil,
+// [http.DefaultTransport] is used. It accepts the same options as [Middleware], although options that only apply
+// to incoming requests (e.g. [WithFilter] or [WithSpanNameFormatter]) are ignored. The carrier configured with
+// [WithTextMapCarrier] is used to inject the span context into the outgoing request.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+
+	return &Transport{
+		base:           base,
+		tracer:         cfg.provider.Tracer(ScopeName, oteltrace.WithInstrumentationVersion(Version)),
+		propagator:     cfg.propagator,
+		carrier:        cfg.carrier,
+		spanOpts:       cfg.spanOpts,
+		sc:             semconv.NewClient(cfg.meter.Meter(ScopeName, metric.WithInstrumentationVersion(Version))),
+		reqHeaders:     cfg.reqHeaders,
+		resHeaders:     cfg.resHeaders,
+		redactor:       cfg.redactor,
+		traceEvents:    cfg.traceEvents,
+		connMetric:     cfg.connMetric,
+		traceExemplars: cfg.traceExemplars,
+	}
+}
+
+// NewClient returns a new [http.Client] that uses a [Transport] wrapping [http.DefaultTransport] and configured with the
+// provided options.
+func NewClient(opts ...Option) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(http.DefaultTransport, opts...),
+	}
+}
+
+// RoundTrip creates a client span for the request, and executes the request using the underlying [http.RoundTripper].
+// The request is not modified, the attributes are computed from a clone of the request, into which the span context is
+// injected.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestStartTime := time.Now()
+
+	// RequestTraceAttrs temporarily clears the user info of the URL, so it must not be called with the caller's
+	// request. The clone holds a deep copy of the URL.
+	r := req.Clone(req.Context())
+	requestTraceAttrs := t.sc.RequestTraceAttrs(r)
+	if r.URL != nil {
+		for i := range requestTraceAttrs {
+			if requestTraceAttrs[i].Key == otelsemconv.URLFullKey {
+				requestTraceAttrs[i] = otelsemconv.URLFull(redactURL(t.redactor, r.URL))
+				break
+			}
+		}
+	}
+
+	opts := []oteltrace.SpanStartOption{
+		oteltrace.WithAttributes(requestTraceAttrs...),
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+	}
+	if attrs := headerAttributes(t.reqHeaders, r.Header, t.redactor); len(attrs) > 0 {
+		opts = append(opts, oteltrace.WithAttributes(attrs...))
+	}
+	opts = append(opts, t.spanOpts...)
+
+	ctx, span := t.tracer.Start(req.Context(), spanMethod(req.Method), opts...)
+	defer span.End()
+
+	if t.traceEvents || t.connMetric {
+		ct := newClientTracer(ctx, span, r, t.sc, t.traceEvents, t.connMetric)
+		ctx = httptrace.WithClientTrace(ctx, ct.clientTrace())
+	}
+
+	r = r.WithContext(ctx)
+	var body *bodyWrapper
+	if r.Body != nil && r.Body != http.NoBody {
+		body = newBodyWrapper(r.Body, span)
+		r.Body = body
+	}
+
+	t.propagator.Inject(ctx, t.carrier(r))
+
+	res, err := t.base.RoundTrip(r)
+
+	var (
+		statusCode           int
+		errorType            string
+		requestSize          int64
+		additionalAttributes []attribute.KeyValue
+	)
+	if body != nil {
+		requestSize = body.BytesRead()
+	}
+
+	if err != nil {
+		errorType = fmt.Sprintf("%T", err)
+		span.SetAttributes(otelsemconv.ErrorTypeKey.String(errorType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		statusCode = res.StatusCode
+		if statusCode < 100 || statusCode >= 400 {
+			errorType = strconv.Itoa(statusCode)
+		}
+		span.SetAttributes(t.sc.ResponseTraceAttrs(res)...)
+		if attrs := headerAttributes(t.resHeaders, res.Header, t.redactor); len(attrs) > 0 {
+			span.SetAttributes(attrs...)
+		}
+		span.SetStatus(t.sc.Status(statusCode))
+	}
+
+	if errorType != "" {
+		additionalAttributes = []attribute.KeyValue{otelsemconv.ErrorTypeKey.String(errorType)}
+	}
+	t.sc.RecordMetrics(exemplarContext(ctx, span, t.traceExemplars), semconv.MetricData{
+		RequestSize: requestSize,
+		ElapsedTime: float64(time.Since(requestStartTime)) / float64(time.Millisecond),
+	}, t.sc.MetricOptions(semconv.MetricAttributes{
+		Req:                  r,
+		StatusCode:           statusCode,
+		AdditionalAttributes: additionalAttributes,
+	}))
+
+	return res, err
+}
@@ -0,0 +1,88 @@
+package oteltracing
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/fox-toolkit/fox"
+)
+
+var _ fox.ResponseWriter = (*responseWriter)(nil)
+
+// responseWriter wraps a [fox.ResponseWriter] to capture the first error returned while writing the response to the
+// client (e.g. broken pipe or connection reset by peer).
+type responseWriter struct {
+	fox.ResponseWriter
+	err error
+}
+
+func newResponseWriter(w fox.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+// Write writes the data to the connection as part of an HTTP reply.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.setError(err)
+	return n, err
+}
+
+// WriteString writes the provided string to the connection as part of an HTTP reply.
+func (w *responseWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.setError(err)
+	return n, err
+}
+
+// ReadFrom reads data from src until EOF or error. The src is passed unchanged to the underlying writer, so that the
+// server can still use sendfile or splice for *os.File and *io.LimitedReader sources. As a consequence, the returned
+// error cannot be attributed to src or to the connection with certainty, and it is only captured if it looks like a
+// connection error (see isConnError).
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	n, err := w.ResponseWriter.ReadFrom(src)
+	if isConnError(err) {
+		w.setError(err)
+	}
+	return n, err
+}
+
+// FlushError flushes buffered data to the client.
+func (w *responseWriter) FlushError() error {
+	err := w.ResponseWriter.FlushError()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		w.setError(err)
+	}
+	return err
+}
+
+// Unwrap returns the underlying [http.ResponseWriter], for use with [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteError returns the first error encountered while writing the response.
+func (w *responseWriter) WriteError() error {
+	return w.err
+}
+
+func (w *responseWriter) setError(err error) {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// isConnError reports whether err is an error of the client connection: a broken pipe, a connection reset, or a
+// network error other than a read, which would come from the source of [responseWriter.ReadFrom].
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op != "read"
+}
@@ -0,0 +1,250 @@
+package oteltracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type brokenPipeWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w brokenPipeWriter) Write([]byte) (int, error) {
+	return 0, syscall.EPIPE
+}
+
+func TestResponseWriteError(t *testing.T) {
+	cases := []struct {
+		name          string
+		w             http.ResponseWriter
+		cancel        bool
+		wantErrorType string
+		wantEvents    int
+	}{
+		{
+			name:          "write error",
+			w:             brokenPipeWriter{httptest.NewRecorder()},
+			wantErrorType: "write_error",
+			wantEvents:    1,
+		},
+		{
+			name:          "client disconnected",
+			w:             httptest.NewRecorder(),
+			cancel:        true,
+			wantErrorType: "client_disconnected",
+		},
+		{
+			name:          "client disconnected with write error",
+			w:             brokenPipeWriter{httptest.NewRecorder()},
+			cancel:        true,
+			wantErrorType: "client_disconnected",
+			wantEvents:    1,
+		},
+		{
+			name: "no error",
+			w:    httptest.NewRecorder(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(
+				fox.WithMiddleware(Middleware("foobar", WithTracerProvider(provider), WithMeterProvider(meter))),
+			)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, err = f.Add(fox.MethodGet, "/stream", func(c *fox.Context) {
+				if tc.cancel {
+					cancel()
+				}
+				err := c.String(http.StatusOK, "hello")
+				if tc.wantEvents > 0 {
+					assert.True(t, errors.Is(err, syscall.EPIPE))
+				}
+			})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+			f.ServeHTTP(tc.w, r)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Len(t, span.Events(), tc.wantEvents)
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			dpErrorType, _ := hist.DataPoints[0].Attributes.Value("error.type")
+			assert.Equal(t, tc.wantErrorType, dpErrorType.AsString())
+			if tc.wantErrorType != "" {
+				assert.Contains(t, span.Attributes(), attribute.String("error.type", tc.wantErrorType))
+			}
+		})
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("upstream failure")
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	cases := []struct {
+		name          string
+		w             http.ResponseWriter
+		src           io.Reader
+		wantErrorType string
+	}{
+		{
+			name: "source error",
+			w:    httptest.NewRecorder(),
+			src:  io.MultiReader(strings.NewReader("hello"), failingReader{}),
+		},
+		{
+			name:          "write error",
+			w:             brokenPipeWriter{httptest.NewRecorder()},
+			src:           io.LimitReader(strings.NewReader("hello"), 5),
+			wantErrorType: "write_error",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter))))
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/file", func(c *fox.Context) {
+				_, err := io.Copy(c.Writer(), tc.src)
+				assert.Error(t, err)
+			})
+			require.NoError(t, err)
+
+			f.ServeHTTP(tc.w, httptest.NewRequest(http.MethodGet, "/file", nil))
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			dpErrorType, _ := hist.DataPoints[0].Attributes.Value("error.type")
+			assert.Equal(t, tc.wantErrorType, dpErrorType.AsString())
+		})
+	}
+}
+
+// readerFromWriter is a writer implementing [io.ReaderFrom], like the response writer of the server.
+type readerFromWriter struct {
+	*httptest.ResponseRecorder
+	src io.Reader
+	err error
+}
+
+func (w *readerFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.src = src
+	if w.err != nil {
+		return 0, w.err
+	}
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestResponseWriterReadFromPassthrough(t *testing.T) {
+	file, err := os.Open("writer.go")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+
+	cases := []struct {
+		name          string
+		src           io.Reader
+		err           error
+		wantErrorType string
+	}{
+		{
+			name: "file",
+			src:  file,
+		},
+		{
+			name: "limited reader",
+			src:  io.LimitReader(strings.NewReader("hello"), 5),
+		},
+		{
+			name:          "connection error",
+			src:           strings.NewReader("hello"),
+			err:           &net.OpError{Op: "readfrom", Net: "tcp", Err: syscall.ECONNRESET},
+			wantErrorType: "write_error",
+		},
+		{
+			name: "source error",
+			src:  strings.NewReader("hello"),
+			err:  &fs.PathError{Op: "read", Path: "file", Err: syscall.EIO},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar", WithMeterProvider(meter))))
+			require.NoError(t, err)
+			_, err = f.Add(fox.MethodGet, "/file", func(c *fox.Context) {
+				_, _ = c.Writer().ReadFrom(tc.src)
+			})
+			require.NoError(t, err)
+
+			w := &readerFromWriter{ResponseRecorder: httptest.NewRecorder(), err: tc.err}
+			f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/file", nil))
+			assert.Same(t, tc.src, w.src)
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			hist := findMetric(t, rm, "http.server.request.duration").Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			dpErrorType, _ := hist.DataPoints[0].Attributes.Value("error.type")
+			assert.Equal(t, tc.wantErrorType, dpErrorType.AsString())
+		})
+	}
+}
+
+func TestResponseWriterUnwrap(t *testing.T) {
+	f, err := fox.NewRouter(fox.WithMiddleware(Middleware("foobar")))
+	require.NoError(t, err)
+	_, err = f.Add(fox.MethodGet, "/flush", func(c *fox.Context) {
+		_, _ = c.Writer().Write([]byte("hello"))
+		assert.NoError(t, http.NewResponseController(c.Writer()).Flush())
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flush", nil))
+	assert.True(t, w.Flushed)
+}